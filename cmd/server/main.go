@@ -3,6 +3,9 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -13,23 +16,101 @@ import (
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/logger"
 )
 
-const pathConfig = "./config/config.yaml"
+const defaultConfigPath = "./config/config.yaml"
+
+// Коды завершения процесса.
+const (
+	exitOK = iota
+	// exitFailure — приложение не запустилось или аварийно остановилось.
+	exitFailure
+	// exitShutdownFailed — остановка не уложилась в ShutdownTimeout или завершилась ошибкой.
+	exitShutdownFailed
+	// exitInvalidConfig — конфигурация не прочитана или не прошла проверку.
+	exitInvalidConfig
+)
 
 func main() {
+	os.Exit(run())
+}
+
+// run запускает приложение и возвращает код завершения. Вынесено из main,
+// чтобы отложенные вызовы выполнялись до os.Exit.
+func run() int {
+	opts := config.LoadOptions{
+		Path:    envOr("CONFIG_PATH", defaultConfigPath),
+		Profile: os.Getenv("APP_ENV"),
+	}
+	flag.StringVar(&opts.Path, "config", opts.Path, "path to the base config file (env CONFIG_PATH)")
+	flag.StringVar(&opts.Profile, "env", opts.Profile,
+		"config profile applied on top of the base file, e.g. prod → config.prod.yaml (env APP_ENV)")
+	checkConfig := flag.Bool("check-config", false, "validate the configuration and exit")
+	flag.Parse()
+
+	if *checkConfig {
+		return runCheckConfig(opts)
+	}
+
 	rootCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	logger := initLogger()
-	cfg := config.LoadConfig(pathConfig)
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	cfg, err := config.LoadConfig(opts)
+	if err != nil {
+		// Формат из конфигурации неизвестен — сообщаем в формате по умолчанию.
+		bootstrap, _, _ := initLogger(os.Stdout, config.LogConfig{Format: logger.FormatPretty}, level)
+		bootstrap.Error("failed to load config", slog.String("error", err.Error()))
+		return exitInvalidConfig
+	}
+	applyLogLevel(level, cfg)
+
+	var logCfg config.LogConfig
+	if cfg.LogConfig != nil {
+		logCfg = *cfg.LogConfig
+	}
+	out, err := logger.OpenOutput(logCfg.Output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidConfig
+	}
+	defer out.Close()
+	log, stopSampling, err := initLogger(out, logCfg, level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidConfig
+	}
+	defer stopSampling()
+	logConfigWarnings(log, cfg)
+
+	watcher := config.NewWatcher(&config.WatcherDeps{Opts: opts, Current: cfg, Log: log})
+	watcher.Subscribe(config.Subscriber{
+		Name:  "logger",
+		Paths: []string{"log.level"},
+		Apply: func(cfg *config.Config) { applyLogLevel(level, cfg) },
+	})
 
 	appCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	a := mustInitApp(appCtx, cfg, logger)
-	a.StartAsync(appCtx)
+	a, err := app.New(appCtx, cfg, log, watcher)
+	if err != nil {
+		log.Error("failed to initialize app", slog.String("error", err.Error()))
+		return exitFailure
+	}
 
-	<-rootCtx.Done()
-	logger.Info("termination signal received, shutting down...")
+	code := exitOK
+	if err := a.StartAsync(appCtx); err != nil {
+		log.Error("failed to start app", slog.String("error", err.Error()))
+		code = exitFailure
+	} else {
+		select {
+		case <-rootCtx.Done():
+			log.Info("termination signal received, shutting down...")
+		case err := <-a.Fatal():
+			log.Error("background task failed, shutting down...", slog.String("error", err.Error()))
+			code = exitFailure
+		}
+	}
 
 	cancel()
 
@@ -37,28 +118,92 @@ func main() {
 	defer cancelShutdown()
 
 	if err := a.Shutdown(shutdownCtx); err != nil {
-		logger.Error("graceful shutdown failed", slog.String("error", err.Error()))
-	} else {
-		logger.Info("service stopped gracefully")
+		log.Error("graceful shutdown failed", slog.String("error", err.Error()))
+		if code == exitOK {
+			code = exitShutdownFailed
+		}
+		return code
 	}
+
+	log.Info("service stopped gracefully")
+	return code
 }
 
-func initLogger() *slog.Logger {
-	logHandler := logger.NewPrettyHandler(os.Stdout, logger.PrettyHandlerOptions{
-		Opts: slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		},
-	})
-	logger := slog.New(logHandler)
-	slog.SetDefault(logger)
-	return logger
+// runCheckConfig проверяет конфигурацию, печатает все найденные проблемы
+// и возвращает код завершения.
+func runCheckConfig(opts config.LoadOptions) int {
+	cfg, err := config.LoadConfig(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitInvalidConfig
+	}
+	for _, w := range cfg.Warnings() {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+	fmt.Fprintf(os.Stdout, "config %s is valid\n", opts.Path)
+	return exitOK
+}
+
+// logConfigWarnings выводит замечания, найденные при загрузке конфигурации.
+func logConfigWarnings(log *slog.Logger, cfg *config.Config) {
+	for _, w := range cfg.Warnings() {
+		log.Warn("config warning", slog.String("detail", w))
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
 
-func mustInitApp(ctx context.Context, cfg *config.Config, log *slog.Logger) *app.App {
-	a, err := app.New(ctx, cfg, log)
+// initLogger создает логгер по разделу log и делает его логгером по умолчанию.
+// Значения атрибутов с чувствительными ключами скрываются во всех форматах.
+// Возвращаемая функция останавливает ограничение частоты записей и выводит
+// сводки о подавленных записях; ее нужно вызвать до закрытия out.
+func initLogger(out io.Writer, cfg config.LogConfig, level slog.Leveler) (*slog.Logger, func(), error) {
+	redactor, err := logger.NewRedactor(cfg.Redact...)
 	if err != nil {
-		log.Error("failed to initialize app", slog.String("error", err.Error()))
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("init logger: %w", err)
+	}
+	logHandler, err := logger.NewHandler(out, cfg.Format, &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   cfg.AddSource,
+		ReplaceAttr: redactor.ReplaceAttr(nil),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("init logger: %w", err)
+	}
+
+	stop := func() {}
+	if cfg.Sampling.Interval > 0 {
+		sampling := logger.NewSamplingHandler(logHandler, logger.SampleOptions{
+			Interval: cfg.Sampling.Interval,
+			Burst:    cfg.Sampling.Burst,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = sampling.Run(ctx)
+		}()
+		stop = func() {
+			cancel()
+			<-done
+		}
+		logHandler = sampling
+	}
+
+	log := slog.New(logHandler)
+	slog.SetDefault(log)
+	return log, stop, nil
+}
+
+// applyLogLevel выставляет уровень логирования из конфигурации. Уровень
+// проверен при загрузке, поэтому ошибка разбора здесь невозможна.
+func applyLogLevel(level *slog.LevelVar, cfg *config.Config) {
+	if l, err := cfg.LogConfig.SlogLevel(); err == nil {
+		level.Set(l)
 	}
-	return a
 }
@@ -0,0 +1,50 @@
+// Package cursor кодирует позиции пагинации истории сообщений в непрозрачные
+// строки, общие для HTTP и gRPC API.
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+)
+
+// token — содержимое непрозрачного курсора до кодирования.
+type token struct {
+	CreatedAt int64  `json:"t"`
+	ID        string `json:"id"`
+}
+
+// Encode кодирует позицию сообщения в непрозрачную строку.
+func Encode(msg *domain.Message) string {
+	raw, _ := json.Marshal(token{ //nolint:errchkjson // структура всегда сериализуема
+		CreatedAt: msg.CreatedAt.UnixNano(),
+		ID:        msg.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode разбирает курсор, полученный от клиента. Пустая строка означает
+// отсутствие курсора; некорректная строка — ошибку domain.ErrInvalidQuery.
+func Decode(s string) (*domain.Cursor, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil // отсутствие курсора не является ошибкой
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	var t token
+	if err := json.Unmarshal(raw, &t); err != nil || t.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	return &domain.Cursor{
+		CreatedAt: time.Unix(0, t.CreatedAt).UTC(),
+		ID:        t.ID,
+	}, nil
+}
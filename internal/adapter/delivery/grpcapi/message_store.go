@@ -0,0 +1,182 @@
+// Package grpcapi реализует gRPC-сервис MessageStore поверх сценариев работы с сообщениями.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/devoraq/AVQ_message_store/internal/adapter/delivery/cursor"
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+	"github.com/devoraq/AVQ_message_store/internal/usecase"
+	messagestorev1 "github.com/devoraq/AVQ_message_store/pkg/api/messagestore/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MessageService описывает сценарии, необходимые gRPC-слою.
+type MessageService interface {
+	Store(ctx context.Context, in usecase.NewMessage) (*domain.Message, error)
+	Get(ctx context.Context, id string) (*domain.Message, error)
+	List(ctx context.Context, q domain.ListQuery) (*usecase.MessagePage, error)
+}
+
+// MessageStoreServer реализует messagestorev1.MessageStoreServer.
+type MessageStoreServer struct {
+	messagestorev1.UnimplementedMessageStoreServer
+
+	deps *MessageStoreDeps
+}
+
+// MessageStoreDeps содержит зависимости gRPC-сервиса сообщений.
+type MessageStoreDeps struct {
+	Messages MessageService
+	Log      *slog.Logger
+}
+
+// NewMessageStoreServer создает gRPC-сервис сообщений.
+func NewMessageStoreServer(deps *MessageStoreDeps) *MessageStoreServer {
+	return &MessageStoreServer{deps: deps}
+}
+
+// Register регистрирует сервис на gRPC-сервере.
+func (s *MessageStoreServer) Register(server *grpc.Server) {
+	messagestorev1.RegisterMessageStoreServer(server, s)
+}
+
+// Store сохраняет сообщение.
+func (s *MessageStoreServer) Store(
+	ctx context.Context,
+	req *messagestorev1.StoreRequest,
+) (*messagestorev1.StoreResponse, error) {
+	in := usecase.NewMessage{
+		ID:          req.GetId(),
+		ChatID:      req.GetChatId(),
+		SenderID:    req.GetSenderId(),
+		ContentType: req.GetContentType(),
+		Payload:     req.GetPayload(),
+	}
+	if req.GetCreatedAt() != nil {
+		in.CreatedAt = req.GetCreatedAt().AsTime()
+	}
+
+	msg, err := s.deps.Messages.Store(ctx, in)
+	if err != nil {
+		return nil, s.toStatus("Store", err)
+	}
+	return &messagestorev1.StoreResponse{Message: toProto(msg)}, nil
+}
+
+// Get возвращает сообщение по идентификатору.
+func (s *MessageStoreServer) Get(
+	ctx context.Context,
+	req *messagestorev1.GetRequest,
+) (*messagestorev1.GetResponse, error) {
+	msg, err := s.deps.Messages.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus("Get", err)
+	}
+	return &messagestorev1.GetResponse{Message: toProto(msg)}, nil
+}
+
+// List передает сообщения чата потоком, читая историю страницами.
+// Без after сообщения идут от новых к старым, с after — от курсора к новым.
+func (s *MessageStoreServer) List(
+	req *messagestorev1.ListRequest,
+	stream grpc.ServerStreamingServer[messagestorev1.ListResponse],
+) error {
+	ctx := stream.Context()
+
+	before, err := cursor.Decode(req.GetBefore())
+	if err != nil {
+		return s.toStatus("List", err)
+	}
+	after, err := cursor.Decode(req.GetAfter())
+	if err != nil {
+		return s.toStatus("List", err)
+	}
+	if req.GetLimit() < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	forward := after != nil && before == nil
+	remaining := int(req.GetLimit())
+
+	for {
+		pageSize := usecase.MaxPageSize
+		if remaining > 0 {
+			pageSize = min(pageSize, remaining)
+		}
+
+		page, err := s.deps.Messages.List(ctx, domain.ListQuery{
+			ChatID: req.GetChatId(),
+			Before: before,
+			After:  after,
+			Limit:  pageSize,
+		})
+		if err != nil {
+			return s.toStatus("List", err)
+		}
+
+		msgs := page.Messages
+		if forward {
+			slices.Reverse(msgs)
+		}
+		for _, msg := range msgs {
+			if err := stream.Send(&messagestorev1.ListResponse{
+				Message: toProto(msg),
+				Cursor:  cursor.Encode(msg),
+			}); err != nil {
+				return err //nolint:wrapcheck // статус ошибки потока формирует grpc
+			}
+		}
+
+		if remaining > 0 {
+			remaining -= len(msgs)
+		}
+		if !page.HasMore || len(msgs) == 0 || req.GetLimit() > 0 && remaining <= 0 {
+			return nil
+		}
+
+		last := &domain.Cursor{CreatedAt: msgs[len(msgs)-1].CreatedAt, ID: msgs[len(msgs)-1].ID}
+		if forward {
+			after = last
+		} else {
+			before = last
+		}
+	}
+}
+
+func (s *MessageStoreServer) toStatus(method string, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrMessageNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidMessage), errors.Is(err, domain.ErrInvalidQuery):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		s.deps.Log.Error("grpc request failed",
+			slog.String("method", method),
+			slog.String("error", err.Error()),
+		)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toProto(msg *domain.Message) *messagestorev1.Message {
+	return &messagestorev1.Message{
+		Id:          msg.ID,
+		ChatId:      msg.ChatID,
+		SenderId:    msg.SenderID,
+		ContentType: msg.ContentType,
+		Payload:     msg.Payload,
+		CreatedAt:   timestamppb.New(msg.CreatedAt),
+		ReceivedAt:  timestamppb.New(msg.ReceivedAt),
+	}
+}
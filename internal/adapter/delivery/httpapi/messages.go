@@ -0,0 +1,199 @@
+// Package httpapi содержит HTTP-обработчики чтения истории сообщений.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/adapter/delivery/cursor"
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/usecase"
+)
+
+// MessageReader описывает сценарии чтения сообщений, нужные HTTP-слою.
+type MessageReader interface {
+	Get(ctx context.Context, id string) (*domain.Message, error)
+	List(ctx context.Context, q domain.ListQuery) (*usecase.MessagePage, error)
+}
+
+// MessageHandler обслуживает HTTP-API истории сообщений.
+type MessageHandler struct {
+	deps *MessageHandlerDeps
+}
+
+// MessageHandlerDeps содержит зависимости HTTP-обработчиков сообщений.
+type MessageHandlerDeps struct {
+	Messages MessageReader
+	Cfg      *config.HTTPConfig
+	Log      *slog.Logger
+}
+
+// messageResponse — JSON-представление сообщения.
+type messageResponse struct {
+	ID          string    `json:"id"`
+	ChatID      string    `json:"chat_id"`
+	SenderID    string    `json:"sender_id"`
+	ContentType string    `json:"content_type"`
+	Payload     string    `json:"payload"`
+	CreatedAt   time.Time `json:"created_at"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+// pageResponse — страница истории. Next передается в параметре before для
+// получения более старых сообщений, Prev — в параметре after для более новых.
+type pageResponse struct {
+	Messages []messageResponse `json:"messages"`
+	Next     string            `json:"next_cursor,omitempty"`
+	Prev     string            `json:"prev_cursor,omitempty"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewMessageHandler создает обработчики HTTP-API сообщений.
+func NewMessageHandler(deps *MessageHandlerDeps) *MessageHandler {
+	return &MessageHandler{deps: deps}
+}
+
+// Register регистрирует маршруты API в переданном мультиплексоре.
+func (h *MessageHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/chats/{chatID}/messages", h.listMessages)
+	mux.HandleFunc("GET /v1/messages/{id}", h.getMessage)
+}
+
+func (h *MessageHandler) getMessage(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.requestContext(r)
+	defer cancel()
+
+	msg, err := h.deps.Messages.Get(ctx, r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toResponse(msg))
+}
+
+func (h *MessageHandler) listMessages(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.requestContext(r)
+	defer cancel()
+
+	q, err := parseListQuery(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	page, err := h.deps.Messages.List(ctx, q)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := pageResponse{Messages: make([]messageResponse, 0, len(page.Messages))}
+	for _, msg := range page.Messages {
+		resp.Messages = append(resp.Messages, toResponse(msg))
+	}
+
+	if n := len(page.Messages); n > 0 {
+		olderAvailable := page.HasMore || q.After != nil
+		newerAvailable := q.Before != nil || q.After != nil && page.HasMore
+		if olderAvailable {
+			resp.Next = cursor.Encode(page.Messages[n-1])
+		}
+		if newerAvailable {
+			resp.Prev = cursor.Encode(page.Messages[0])
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requestContext ограничивает обработку запроса таймаутом записи сервера,
+// чтобы обращение к хранилищу не пережило соединение.
+func (h *MessageHandler) requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if h.deps.Cfg.WriteTimeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), h.deps.Cfg.WriteTimeout)
+}
+
+func parseListQuery(r *http.Request) (domain.ListQuery, error) {
+	values := r.URL.Query()
+	q := domain.ListQuery{ChatID: r.PathValue("chatID")}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return q, fmt.Errorf("%w: %w", domain.ErrInvalidQuery, err)
+		}
+		q.Limit = limit
+	}
+
+	before, err := cursor.Decode(values.Get("before"))
+	if err != nil {
+		return q, err
+	}
+	after, err := cursor.Decode(values.Get("after"))
+	if err != nil {
+		return q, err
+	}
+	q.Before, q.After = before, after
+
+	return q, nil
+}
+
+func (h *MessageHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusInternalServerError, "internal"
+	switch {
+	case errors.Is(err, domain.ErrMessageNotFound):
+		status, code = http.StatusNotFound, "not_found"
+	case errors.Is(err, domain.ErrInvalidQuery), errors.Is(err, domain.ErrInvalidMessage):
+		status, code = http.StatusBadRequest, "invalid_request"
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code = http.StatusGatewayTimeout, "timeout"
+	}
+
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		h.deps.Log.Error("http request failed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("error", err.Error()),
+		)
+		msg = http.StatusText(status)
+	}
+
+	writeJSON(w, status, errorResponse{Error: errorBody{Code: code, Message: msg}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func toResponse(msg *domain.Message) messageResponse {
+	return messageResponse{
+		ID:          msg.ID,
+		ChatID:      msg.ChatID,
+		SenderID:    msg.SenderID,
+		ContentType: msg.ContentType,
+		Payload:     string(msg.Payload),
+		CreatedAt:   msg.CreatedAt,
+		ReceivedAt:  msg.ReceivedAt,
+	}
+}
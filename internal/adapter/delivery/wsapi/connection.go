@@ -0,0 +1,210 @@
+package wsapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+	"github.com/gorilla/websocket"
+)
+
+// connection обслуживает одно веб-сокет соединение. Чтение кадров, их
+// обработка и запись подтверждений выполняются в разных горутинах; окно
+// window ограничивает число одновременно обрабатываемых кадров.
+type connection struct {
+	h    *Handler
+	conn *websocket.Conn
+	log  *slog.Logger
+
+	window chan struct{}
+	acks   chan ackFrame
+	wg     sync.WaitGroup
+}
+
+func newConnection(h *Handler, conn *websocket.Conn) *connection {
+	window := max(h.deps.Cfg.MaxInFlight, 1)
+	return &connection{
+		h:      h,
+		conn:   conn,
+		log:    h.deps.Log.With(slog.String("component", "websocket"), slog.String("remote", conn.RemoteAddr().String())),
+		window: make(chan struct{}, window),
+		acks:   make(chan ackFrame, window),
+	}
+}
+
+// run читает кадры до ошибки чтения или остановки сервиса, дожидается
+// обработки принятых кадров, отправляет подтверждения и закрывает соединение.
+func (c *connection) run(ctx context.Context) {
+	c.log.Debug("websocket connection opened")
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writeLoop()
+	}()
+
+	stopWatch := c.watchClosing()
+	c.readLoop(ctx)
+	stopWatch()
+
+	c.wg.Wait()
+	close(c.acks)
+	<-writerDone
+
+	if err := c.conn.Close(); err != nil {
+		c.log.Debug("websocket close failed", slog.String("error", err.Error()))
+	}
+	c.log.Debug("websocket connection closed")
+}
+
+// watchClosing прерывает блокирующее чтение при остановке сервиса.
+func (c *connection) watchClosing() (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-c.h.closing:
+			_ = c.conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (c *connection) readLoop(ctx context.Context) {
+	cfg := c.h.deps.Cfg
+	pongWait := 2 * cfg.PingInterval
+
+	c.conn.SetReadLimit(cfg.MaxMessageSize)
+	c.extendReadDeadline(pongWait)
+	c.conn.SetPongHandler(func(string) error {
+		c.extendReadDeadline(pongWait)
+		return nil
+	})
+
+	for {
+		// Слот окна занимается до чтения кадра: пока окно заполнено,
+		// кадры не читаются и отправитель упирается в TCP-буфер.
+		select {
+		case c.window <- struct{}{}:
+		case <-c.h.closing:
+			return
+		}
+
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			<-c.window
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.log.Debug("websocket read finished", slog.String("error", err.Error()))
+			}
+			return
+		}
+		c.extendReadDeadline(pongWait)
+
+		var frame inboundFrame
+		if err := json.Unmarshal(data, &frame); err != nil || len(frame.Message) == 0 {
+			c.acks <- errorAck(frame.Seq, codeInvalidFrame, "frame must be a JSON object with seq and message")
+			<-c.window
+			continue
+		}
+
+		c.wg.Add(1)
+		go c.process(ctx, frame)
+	}
+}
+
+func (c *connection) extendReadDeadline(wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+	_ = c.conn.SetReadDeadline(time.Now().Add(wait))
+}
+
+// process сохраняет сообщение кадра и ставит подтверждение в очередь записи.
+// Обработка не прерывается при закрытии соединения.
+func (c *connection) process(ctx context.Context, frame inboundFrame) {
+	defer c.wg.Done()
+	defer func() { <-c.window }()
+
+	processCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	if timeout := c.h.deps.Cfg.ProcessTimeout; timeout > 0 {
+		processCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	}
+	defer cancel()
+
+	msg, err := c.h.deps.Messages.Ingest(processCtx, frame.Message, domain.Source{})
+	if err != nil {
+		c.acks <- c.errorAck(frame.Seq, err)
+		return
+	}
+	c.acks <- okAck(frame.Seq, msg.ID)
+}
+
+func (c *connection) errorAck(seq uint64, err error) ackFrame {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMessage):
+		return errorAck(seq, codeInvalidMessage, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorAck(seq, codeTimeout, "message processing timed out")
+	default:
+		c.log.Error("websocket message failed", slog.Uint64("seq", seq), slog.String("error", err.Error()))
+		return errorAck(seq, codeInternal, "internal error")
+	}
+}
+
+// writeLoop отправляет подтверждения и пинги. После ошибки записи
+// подтверждения продолжают вычитываться, чтобы не блокировать обработчики.
+func (c *connection) writeLoop() {
+	cfg := c.h.deps.Cfg
+
+	var ping <-chan time.Time
+	if cfg.PingInterval > 0 {
+		ticker := time.NewTicker(cfg.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	broken := false
+	for {
+		select {
+		case ack, ok := <-c.acks:
+			if !ok {
+				if !broken {
+					c.writeClose()
+				}
+				return
+			}
+			if broken {
+				continue
+			}
+			_ = c.conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			if err := c.conn.WriteJSON(ack); err != nil {
+				c.log.Debug("websocket write failed", slog.String("error", err.Error()))
+				broken = true
+			}
+		case <-ping:
+			if broken {
+				continue
+			}
+			deadline := time.Now().Add(cfg.WriteTimeout)
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.log.Debug("websocket ping failed", slog.String("error", err.Error()))
+				broken = true
+			}
+		}
+	}
+}
+
+func (c *connection) writeClose() {
+	code := websocket.CloseNormalClosure
+	select {
+	case <-c.h.closing:
+		code = websocket.CloseGoingAway
+	default:
+	}
+	deadline := time.Now().Add(c.h.deps.Cfg.WriteTimeout)
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), deadline)
+}
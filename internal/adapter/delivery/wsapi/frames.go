@@ -0,0 +1,45 @@
+package wsapi
+
+import "encoding/json"
+
+// Статусы кадра подтверждения.
+const (
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+// Коды ошибок, которые получает отправитель в кадре подтверждения.
+const (
+	codeInvalidFrame   = "invalid_frame"
+	codeInvalidMessage = "invalid_message"
+	codeTimeout        = "timeout"
+	codeInternal       = "internal"
+)
+
+// inboundFrame — кадр с сообщением от клиента. Seq выбирается клиентом и
+// возвращается в подтверждении, Message имеет формат входящего сообщения.
+type inboundFrame struct {
+	Seq     uint64          `json:"seq"`
+	Message json.RawMessage `json:"message"`
+}
+
+// ackFrame — подтверждение обработки кадра с номером Seq.
+type ackFrame struct {
+	Seq    uint64    `json:"seq"`
+	Status string    `json:"status"`
+	ID     string    `json:"id,omitempty"`
+	Error  *ackError `json:"error,omitempty"`
+}
+
+type ackError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func okAck(seq uint64, id string) ackFrame {
+	return ackFrame{Seq: seq, Status: statusOK, ID: id}
+}
+
+func errorAck(seq uint64, code, msg string) ackFrame {
+	return ackFrame{Seq: seq, Status: statusError, Error: &ackError{Code: code, Message: msg}}
+}
@@ -0,0 +1,109 @@
+// Package wsapi реализует приём сообщений по веб-сокету с подтверждением
+// обработки каждого кадра.
+package wsapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/gorilla/websocket"
+)
+
+// MessageIngester описывает сценарий сохранения входящего сообщения.
+type MessageIngester interface {
+	Ingest(ctx context.Context, raw []byte, src domain.Source) (*domain.Message, error)
+}
+
+// Handler принимает веб-сокет соединения и обслуживает их до закрытия
+// клиентом или остановки сервиса.
+type Handler struct {
+	deps     *HandlerDeps
+	upgrader websocket.Upgrader
+
+	// mu делает проверку closed и регистрацию соединения в conns атомарными
+	// относительно Shutdown: после закрытия новые соединения не принимаются,
+	// и conns.Add не вызывается во время conns.Wait.
+	mu      sync.Mutex
+	closed  bool
+	closing chan struct{}
+	conns   sync.WaitGroup
+}
+
+// HandlerDeps содержит зависимости веб-сокет обработчика.
+type HandlerDeps struct {
+	Messages MessageIngester
+	Cfg      *config.WebSocketConfig
+	Log      *slog.Logger
+}
+
+// NewHandler создает веб-сокет обработчик.
+func NewHandler(deps *HandlerDeps) *Handler {
+	return &Handler{
+		deps:    deps,
+		closing: make(chan struct{}),
+	}
+}
+
+// Register регистрирует маршрут веб-сокета в переданном мультиплексоре.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/ws/messages", h.serve)
+}
+
+// Shutdown просит все открытые соединения завершиться и ждет их закрытия:
+// чтение новых кадров прекращается, а уже принятые кадры обрабатываются
+// и подтверждаются. http.Server.Shutdown такие соединения не отслеживает.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.closing)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("websocket shutdown: %w", ctx.Err())
+	}
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request) {
+	if !h.acquire() {
+		http.Error(w, "service is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.conns.Done()
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader уже ответил клиенту ошибкой.
+		h.deps.Log.Debug("websocket upgrade failed", slog.String("error", err.Error()))
+		return
+	}
+
+	newConnection(h, conn).run(r.Context())
+}
+
+// acquire регистрирует соединение, если обработчик еще не остановлен.
+func (h *Handler) acquire() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.conns.Add(1)
+	return true
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrCreateIndexes сообщает о сбое при создании индексов коллекции.
+	ErrCreateIndexes = errors.New("repository: create indexes failed")
+	// ErrInsertMessage сигнализирует об ошибке записи сообщения.
+	ErrInsertMessage = errors.New("repository: insert message failed")
+	// ErrFindMessage означает ошибку чтения сообщения.
+	ErrFindMessage = errors.New("repository: find message failed")
+)
@@ -0,0 +1,486 @@
+// Package repository содержит реализации доменных репозиториев поверх MongoDB.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/nosql/mongodb"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"github.com/devoraq/AVQ_message_store/pkg/retry/mongoretry"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MessageRepository сохраняет и читает сообщения из коллекции MongoDB.
+// Реализует domain.MessageRepository и app.Component: индексы создаются в Start.
+type MessageRepository struct {
+	name string
+	deps *MessageRepositoryDeps
+	coll *mongo.Collection
+}
+
+// MessageRepositoryDeps содержит зависимости репозитория сообщений.
+// Retry может быть nil — тогда запись повторяется по Cfg.WriteRetry.
+// Breaker может быть nil — тогда запись не защищена предохранителем.
+type MessageRepositoryDeps struct {
+	Mongo   *mongodb.MongoDB
+	Cfg     *config.MongoConfig
+	Log     *slog.Logger
+	Retry   *retry.Registry
+	Breaker *retry.Breaker
+}
+
+var _ domain.MessageRepository = (*MessageRepository)(nil)
+
+// messageDocument — представление сообщения в MongoDB.
+type messageDocument struct {
+	ID          bson.ObjectID `bson:"_id"`
+	Key         string        `bson:"key,omitempty"`
+	ChatID      string        `bson:"chat_id"`
+	SenderID    string        `bson:"sender_id"`
+	Payload     []byte        `bson:"payload"`
+	ContentType string        `bson:"content_type"`
+	CreatedAt   time.Time     `bson:"created_at"`
+	ReceivedAt  time.Time     `bson:"received_at"`
+	Source      sourceDoc     `bson:"source"`
+}
+
+type sourceDoc struct {
+	Topic     string `bson:"topic"`
+	Partition int    `bson:"partition"`
+	Offset    int64  `bson:"offset"`
+}
+
+// NewMessageRepository создает репозиторий сообщений поверх переданного клиента MongoDB.
+func NewMessageRepository(deps *MessageRepositoryDeps) *MessageRepository {
+	return &MessageRepository{
+		name: "message-repository",
+		deps: deps,
+		coll: deps.Mongo.GetDB(deps.Cfg.DB).Collection(deps.Cfg.Collection),
+	}
+}
+
+// Name возвращает имя компонента.
+func (r *MessageRepository) Name() string { return r.name }
+
+// DependsOn сообщает контейнеру, что репозиторий запускается после MongoDB.
+func (r *MessageRepository) DependsOn() []string { return []string{r.deps.Mongo.Name()} }
+
+// Start создает индексы коллекции сообщений. Операция идемпотентна.
+func (r *MessageRepository) Start(ctx context.Context) error {
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "chat_id", Value: 1},
+				{Key: "created_at", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().SetName("chat_created_at"),
+		},
+		{
+			// Сообщения без ключа в уникальный индекс не попадают.
+			Keys: bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().
+				SetName("key_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{
+					{Key: "key", Value: bson.D{{Key: "$type", Value: "string"}}},
+				}),
+		},
+	}
+
+	names, err := r.coll.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateIndexes, err)
+	}
+
+	r.deps.Log.Debug("Message indexes ensured",
+		slog.String("component", r.name),
+		slog.String("collection", r.deps.Cfg.Collection),
+		slog.Any("indexes", names),
+	)
+	return nil
+}
+
+// Stop ничего не делает: соединением владеет компонент mongodb.
+func (r *MessageRepository) Stop(_ context.Context) error { return nil }
+
+// Save сохраняет сообщение. Если идентификатор не задан, он генерируется.
+// Временные сбои записи повторяются по политике config.RetryMongoWrite.
+// Как и в SaveMany, дубликат, который при повторе совпал с документом,
+// записанным предыдущей попыткой того же вызова, считается сохраненным.
+func (r *MessageRepository) Save(ctx context.Context, msg *domain.Message) error {
+	doc, err := toDocument(msg)
+	if err != nil {
+		return err
+	}
+
+	attempts := 0
+	err = r.write(ctx, "insert_one", func(ctx context.Context) error {
+		attempts++
+		_, err := r.coll.InsertOne(ctx, doc)
+		return err //nolint:wrapcheck // оборачивается после повторов
+	})
+	if err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %w", ErrInsertMessage, err)
+		}
+		failed := map[int]error{0: fmt.Errorf("%w: %w", domain.ErrDuplicateMessage, err)}
+		if attempts > 1 {
+			// Неудачная по таймауту попытка могла записать документ.
+			r.resolveOwnDuplicates(ctx, []bson.ObjectID{doc.ID}, failed)
+		}
+		if err := failed[0]; err != nil {
+			return err
+		}
+	}
+
+	msg.ID = doc.ID.Hex()
+	return nil
+}
+
+// SaveMany сохраняет пачку сообщений одним InsertMany с ordered=false, так что
+// ошибка одного документа не мешает записи остальных. Ошибки отдельных
+// документов возвращаются в *domain.BatchError по их индексам. Повтор
+// отправляет только документы, судьба которых неизвестна: записанные и
+// отклоненные предыдущей попыткой исключаются. Документ, который при повторе
+// совпал с записанным ранее попыткой того же вызова, считается сохраненным.
+func (r *MessageRepository) SaveMany(ctx context.Context, msgs []*domain.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	docs := make([]any, 0, len(msgs))
+	ids := make([]bson.ObjectID, 0, len(msgs))
+	for _, msg := range msgs {
+		doc, err := toDocument(msg)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+		ids = append(ids, doc.ID)
+	}
+
+	failed := make(map[int]error)
+	pending := make([]int, len(docs))
+	for i := range pending {
+		pending[i] = i
+	}
+	attempts := 0
+
+	err := r.write(ctx, "insert_many", func(ctx context.Context) error {
+		attempts++
+		batch := make([]any, len(pending))
+		for j, i := range pending {
+			batch[j] = docs[i]
+		}
+
+		_, err := r.coll.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) {
+			// Успех или сбой пачки целиком: при сбое повторяются все оставшиеся.
+			return err //nolint:wrapcheck // оборачивается после повторов
+		}
+
+		// Ошибки отдельных документов повтором не исправить.
+		rejected := make(map[int]bool, len(bulkErr.WriteErrors))
+		for _, we := range bulkErr.WriteErrors {
+			i := pending[we.Index]
+			rejected[i] = true
+			if isDuplicateKeyCode(we.Code) {
+				failed[i] = fmt.Errorf("%w: %w", domain.ErrDuplicateMessage, we)
+				continue
+			}
+			failed[i] = fmt.Errorf("%w: %w", ErrInsertMessage, we)
+		}
+		if bulkErr.WriteConcernError == nil {
+			pending = nil
+			return nil
+		}
+		// Запись не подтверждена: повторяем документы без собственных ошибок.
+		pending = slices.DeleteFunc(pending, func(i int) bool { return rejected[i] })
+		return err //nolint:wrapcheck // оборачивается после повторов
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInsertMessage, err)
+	}
+
+	if attempts > 1 {
+		r.resolveOwnDuplicates(ctx, ids, failed)
+	}
+	assignIDs(msgs, ids, failed)
+	if len(failed) > 0 {
+		return &domain.BatchError{Errors: failed}
+	}
+	return nil
+}
+
+// resolveOwnDuplicates убирает из failed дубликаты, которые на самом деле
+// записаны предыдущей попыткой того же вызова: у них совпадает _id. Если
+// проверить не удалось, дубликаты остаются как есть.
+func (r *MessageRepository) resolveOwnDuplicates(ctx context.Context, ids []bson.ObjectID, failed map[int]error) {
+	var dups []bson.ObjectID
+	for i, err := range failed {
+		if errors.Is(err, domain.ErrDuplicateMessage) {
+			dups = append(dups, ids[i])
+		}
+	}
+	if len(dups) == 0 {
+		return
+	}
+
+	cur, err := r.coll.Find(ctx,
+		bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: dups}}}},
+		options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		r.deps.Log.WarnContext(ctx, "failed to resolve batch duplicates", slog.Any("error", err))
+		return
+	}
+	var stored []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &stored); err != nil {
+		r.deps.Log.WarnContext(ctx, "failed to resolve batch duplicates", slog.Any("error", err))
+		return
+	}
+
+	own := make(map[bson.ObjectID]bool, len(stored))
+	for _, doc := range stored {
+		own[doc.ID] = true
+	}
+	for i := range failed {
+		if own[ids[i]] {
+			delete(failed, i)
+		}
+	}
+}
+
+// write выполняет запись с повторами по политике config.RetryMongoWrite.
+// Повторяются только временные ошибки (см. mongoretry.Classify). Каждая
+// попытка проходит через предохранитель: пока он разомкнут, запись сразу
+// завершается ошибкой retry.ErrBreakerOpen. Ошибка, которую классификатор
+// счел постоянной, помечается retry.Permanent, чтобы вызывающий (например,
+// консюмер Kafka) не повторял ее. Временная ошибка, оставшаяся после
+// исчерпания попыток, возвращается без пометки: вызывающий может повторить
+// запись позже или дождаться предохранителя.
+func (r *MessageRepository) write(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	policy := r.deps.Cfg.WriteRetry.Policy()
+	var budget *retry.Budget
+	if r.deps.Retry != nil {
+		policy = r.deps.Retry.Policy(config.RetryMongoWrite)
+		budget = r.deps.Retry.Budget(config.RetryMongoWrite)
+	}
+	if b := r.deps.Breaker; b != nil {
+		guarded := fn
+		fn = func(ctx context.Context) error { return b.Execute(ctx, guarded) }
+	}
+
+	err := retry.Do(ctx, policy, fn,
+		retry.WithName(config.RetryMongoWrite),
+		retry.WithClassifier(mongoretry.Classify),
+		retry.WithBudget(budget),
+		retry.OnRetry(func(attempt int, delay time.Duration, err error) {
+			r.deps.Log.WarnContext(ctx, "mongo write retry",
+				slog.String("op", op),
+				slog.Int("attempt", attempt),
+				slog.Duration("delay", delay),
+				slog.Any("error", err),
+			)
+		}),
+	)
+	if err != nil && !retry.Retryable(err, mongoretry.Classify) {
+		return retry.Permanent(err) //nolint:wrapcheck // ошибку оборачивает вызывающий
+	}
+	return err //nolint:wrapcheck // ошибку оборачивает вызывающий
+}
+
+func assignIDs(msgs []*domain.Message, ids []bson.ObjectID, failed map[int]error) {
+	for i, msg := range msgs {
+		if _, ok := failed[i]; ok {
+			continue
+		}
+		msg.ID = ids[i].Hex()
+	}
+}
+
+// GetByID возвращает сообщение по идентификатору.
+func (r *MessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrMessageNotFound, err)
+	}
+
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: oid}})
+}
+
+// GetByKey возвращает сообщение по идемпотентному ключу.
+func (r *MessageRepository) GetByKey(ctx context.Context, key string) (*domain.Message, error) {
+	if key == "" {
+		return nil, domain.ErrMessageNotFound
+	}
+	return r.findOne(ctx, bson.D{{Key: "key", Value: key}})
+}
+
+// ListByChat возвращает страницу сообщений чата, упорядоченную от новых к старым.
+// Для запроса с After сообщения выбираются по возрастанию от курсора и
+// разворачиваются, чтобы страница примыкала к курсору.
+func (r *MessageRepository) ListByChat(ctx context.Context, q domain.ListQuery) ([]*domain.Message, error) {
+	filter := bson.D{{Key: "chat_id", Value: q.ChatID}}
+	order := -1
+
+	var bounds bson.A
+	if q.Before != nil {
+		cond, err := cursorFilter(q.Before, "$lt")
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, cond)
+	}
+	if q.After != nil {
+		cond, err := cursorFilter(q.After, "$gt")
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, cond)
+		if q.Before == nil {
+			order = 1
+		}
+	}
+	if len(bounds) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: bounds})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(q.Limit))
+
+	docs, err := read(ctx, r, func(ctx context.Context) ([]messageDocument, error) {
+		cur, err := r.coll.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFindMessage, err)
+		}
+
+		var docs []messageDocument
+		if err := cur.All(ctx, &docs); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFindMessage, err)
+		}
+		return docs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*domain.Message, len(docs))
+	for i := range docs {
+		out[i] = docs[i].toDomain()
+	}
+	if order > 0 {
+		slices.Reverse(out)
+	}
+	return out, nil
+}
+
+// cursorFilter строит условие «строго до/после курсора» по паре (created_at, _id).
+func cursorFilter(c *domain.Cursor, op string) (bson.D, error) {
+	oid, err := bson.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor id %q", domain.ErrInvalidQuery, c.ID)
+	}
+	createdAt := c.CreatedAt.UTC()
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "created_at", Value: bson.D{{Key: op, Value: createdAt}}}},
+		bson.D{
+			{Key: "created_at", Value: createdAt},
+			{Key: "_id", Value: bson.D{{Key: op, Value: oid}}},
+		},
+	}}}, nil
+}
+
+func (r *MessageRepository) findOne(ctx context.Context, filter bson.D) (*domain.Message, error) {
+	return read(ctx, r, func(ctx context.Context) (*domain.Message, error) {
+		var doc messageDocument
+		if err := r.coll.FindOne(ctx, filter).Decode(&doc); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, domain.ErrMessageNotFound
+			}
+			return nil, fmt.Errorf("%w: %w", ErrFindMessage, err)
+		}
+		return doc.toDomain(), nil
+	})
+}
+
+// read выполняет идемпотентное чтение со страхующими попытками по политике
+// config.RetryMongoRead: медленный запрос дублируется, побеждает первый ответ.
+func read[T any](ctx context.Context, r *MessageRepository, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy := r.deps.Cfg.ReadRetry.Policy()
+	var budget *retry.Budget
+	if r.deps.Retry != nil {
+		policy = r.deps.Retry.Policy(config.RetryMongoRead)
+		budget = r.deps.Retry.Budget(config.RetryMongoRead)
+	}
+
+	return retry.Hedge(ctx, policy, fn, //nolint:wrapcheck // fn возвращает ошибки репозитория
+		retry.WithClassifier(mongoretry.Classify),
+		retry.WithBudget(budget),
+	)
+}
+
+// isDuplicateKeyCode распознает коды ошибок нарушения уникального индекса.
+func isDuplicateKeyCode(code int) bool {
+	return code == 11000 || code == 11001 || code == 12582
+}
+
+func toDocument(msg *domain.Message) (*messageDocument, error) {
+	id := bson.NewObjectID()
+	if msg.ID != "" {
+		oid, err := bson.ObjectIDFromHex(msg.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed id %q", domain.ErrInvalidMessage, msg.ID)
+		}
+		id = oid
+	}
+
+	return &messageDocument{
+		ID:          id,
+		Key:         msg.Key,
+		ChatID:      msg.ChatID,
+		SenderID:    msg.SenderID,
+		Payload:     msg.Payload,
+		ContentType: msg.ContentType,
+		CreatedAt:   msg.CreatedAt.UTC(),
+		ReceivedAt:  msg.ReceivedAt.UTC(),
+		Source: sourceDoc{
+			Topic:     msg.Source.Topic,
+			Partition: msg.Source.Partition,
+			Offset:    msg.Source.Offset,
+		},
+	}, nil
+}
+
+func (d *messageDocument) toDomain() *domain.Message {
+	return &domain.Message{
+		ID:          d.ID.Hex(),
+		Key:         d.Key,
+		ChatID:      d.ChatID,
+		SenderID:    d.SenderID,
+		Payload:     d.Payload,
+		ContentType: d.ContentType,
+		CreatedAt:   d.CreatedAt,
+		ReceivedAt:  d.ReceivedAt,
+		Source: domain.Source{
+			Topic:     d.Source.Topic,
+			Partition: d.Source.Partition,
+			Offset:    d.Source.Offset,
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestWriteMarksOnlyPermanentErrors(t *testing.T) {
+	transient := mongo.CommandError{Code: 91, Message: "shutdown in progress", Labels: []string{"RetryableWriteError"}}
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+
+	tests := []struct {
+		name string
+		// errs — ошибки попыток по порядку; попытки сверх списка успешны.
+		errs          []error
+		wantCalls     int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{
+			name:      "transient error recovers",
+			errs:      []error{transient},
+			wantCalls: 2,
+		},
+		{
+			// Без предохранителя временный сбой после исчерпания попыток
+			// должен остаться повторяемым для консюмера Kafka.
+			name:      "transient error exhausts attempts",
+			errs:      []error{transient, transient, transient},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:          "duplicate key is permanent",
+			errs:          []error{duplicate},
+			wantCalls:     1,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MessageRepository{deps: &MessageRepositoryDeps{
+				Cfg: &config.MongoConfig{WriteRetry: config.RetryConfig{
+					Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond, Factor: 1,
+				}},
+				Log: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}}
+
+			calls := 0
+			err := r.write(context.Background(), "test", func(context.Context) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Fatalf("attempts = %d, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if got := retry.IsPermanent(err); got != tt.wantPermanent {
+				t.Fatalf("IsPermanent(%v) = %t, want %t", err, got, tt.wantPermanent)
+			}
+			// Так ошибку классифицирует консюмер Kafka (см. handleWithRetry).
+			if got := retry.Retryable(err, nil); got == tt.wantPermanent {
+				t.Fatalf("Retryable(%v) = %t, want %t", err, got, !tt.wantPermanent)
+			}
+			if tt.wantPermanent && !mongo.IsDuplicateKeyError(err) {
+				t.Fatalf("permanent mark hides the duplicate key error: %v", err)
+			}
+			if !tt.wantPermanent && !errors.As(err, &mongo.CommandError{}) {
+				t.Fatalf("err = %v, want the last transient error", err)
+			}
+		})
+	}
+}
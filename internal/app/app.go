@@ -9,45 +9,131 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 
+	"github.com/devoraq/AVQ_message_store/internal/adapter/delivery/grpcapi"
+	"github.com/devoraq/AVQ_message_store/internal/adapter/delivery/httpapi"
+	"github.com/devoraq/AVQ_message_store/internal/adapter/delivery/wsapi"
+	"github.com/devoraq/AVQ_message_store/internal/adapter/repository"
+	"github.com/devoraq/AVQ_message_store/internal/app/gapp"
 	"github.com/devoraq/AVQ_message_store/internal/app/happ"
+	"github.com/devoraq/AVQ_message_store/internal/domain"
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/eventbus/kafka"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/metrics"
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/nosql/mongodb"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/tracing"
+	"github.com/devoraq/AVQ_message_store/internal/usecase"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"github.com/devoraq/AVQ_message_store/pkg/retry/mongoretry"
+	"google.golang.org/grpc"
 )
 
 // App управляет жизненным циклом компонентов приложения.
 type App struct {
-	log  *slog.Logger
-	happ *happ.HApp
+	log   *slog.Logger
+	happ  *happ.HApp
+	gapp  *gapp.GApp
+	ws    *wsapi.Handler
+	kafka *kafka.Kafka
 
 	container *Container
+	ready     atomic.Bool
 
-	wg           sync.WaitGroup
+	watcher *config.Watcher
+	// stopBackground отменяет консюмер и наблюдатель за конфигурацией.
+	stopBackground context.CancelFunc
+
+	group        *runGroup
 	shutdownOnce sync.Once
 }
 
 // New создает контейнер приложения и подготавливает инфраструктурные компоненты.
-func New(_ context.Context, cfg *config.Config, log *slog.Logger) (*App, error) {
+// Если передан watcher, компоненты подписываются на изменения конфигурации,
+// которые могут применить без перезапуска.
+func New(_ context.Context, cfg *config.Config, log *slog.Logger, watcher *config.Watcher) (*App, error) {
+	m := metrics.New()
+	policies := retry.NewRegistry(cfg.RetryPolicies())
 	app := &App{
 		log:       log,
-		container: NewContainer(log, cfg),
+		container: NewContainer(log, policies, m),
+		group:     newRunGroup(),
+		watcher:   watcher,
+	}
+
+	tracer := tracing.New(&tracing.TracingDeps{Cfg: cfg.TracingConfig, Log: log})
+	mongo, err := initMongo(cfg, log, m)
+	if err != nil {
+		return nil, err
 	}
+	storage := initStorageBreaker(cfg.MongoConfig, log, m)
+	messages := initMessageRepository(cfg, mongo, log, policies, storage)
+	kafka := initKafka(cfg, log, m, policies, storage)
 
-	mongo := mustInitMongo(cfg, log)
-	kafka := mustInitKafka(cfg, log)
+	// Трассировка нужна всем остальным компонентам: так она останавливается
+	// последней и успевает выгрузить их спаны. Консюмер Kafka пишет через
+	// репозиторий, поэтому без него не запускается.
+	app.container.Add(tracer)
+	app.container.AddWithDeps(mongo, tracer.Name())
+	app.container.Add(messages)
+	app.container.AddWithDeps(kafka, tracer.Name(), messages.Name())
 
-	app.container.Add(mongo, kafka)
+	messageUC := usecase.NewMessageUseCase(&usecase.MessageDeps{
+		Repo: messages,
+		Log:  log,
+	})
+	kafka.AddDeliveryHandler(ingestHandler(messageUC))
+	kafka.SetBatchHandler(ingestBatchHandler(messageUC))
+	app.kafka = kafka
+
+	if watcher != nil {
+		watcher.Subscribe(config.Subscriber{
+			Name:  "retry policies",
+			Paths: config.RetryPolicyPaths,
+			Apply: func(cfg *config.Config) { policies.Update(cfg.RetryPolicies()) },
+		})
+		watcher.Subscribe(config.Subscriber{
+			Name:  kafka.Name(),
+			Paths: kafka.ReloadablePaths(),
+			Apply: kafka.ApplyConfig,
+		})
+	}
 
 	if cfg.IsHTTPEnabled {
-		app.happ = buildHTTP(cfg.HTTPConfig, log)
+		app.ws = wsapi.NewHandler(&wsapi.HandlerDeps{
+			Messages: messageUC,
+			Cfg:      &cfg.WebSocket,
+			Log:      log,
+		})
+		app.happ = buildHTTP(cfg.HTTPConfig, log, m,
+			httpapi.NewMessageHandler(&httpapi.MessageHandlerDeps{
+				Messages: messageUC,
+				Cfg:      cfg.HTTPConfig,
+				Log:      log,
+			}),
+			app.ws,
+			&healthHandler{container: app.container, ready: &app.ready},
+			m,
+		)
+	}
+
+	if cfg.IsGrpcEnabled {
+		app.gapp = buildGRPC(cfg.GRPCConfig, log,
+			grpcapi.NewMessageStoreServer(&grpcapi.MessageStoreDeps{
+				Messages: messageUC,
+				Log:      log,
+			}),
+		)
 	}
 
 	return app, nil
 }
 
-// StartAsync запускает фоновые компоненты, включая HTTP-сервер.
-func (a *App) StartAsync(ctx context.Context) {
+// StartAsync запускает компоненты и фоновые задачи (консюмер, HTTP- и
+// gRPC-серверы) и сразу возвращает управление. Ошибка означает, что
+// компоненты не запустились; освободить уже запущенное нужно через Shutdown.
+// Фатальные ошибки фоновых задач после запуска приходят в Fatal.
+func (a *App) StartAsync(ctx context.Context) error {
 	const op = "App.StartAsync"
 	log := a.log.With("op", op)
 
@@ -57,18 +143,39 @@ func (a *App) StartAsync(ctx context.Context) {
 	)
 
 	if err := a.container.StartAll(ctx); err != nil {
-		log.Error("failed to start components", slog.Any("error", err))
-		os.Exit(1)
+		return fmt.Errorf("start components: %w", err)
 	}
 
-	// if a.GRPC != nil {
-	// 	go a.GRPC.MustStart()
-	// }
+	runCtx, cancel := context.WithCancel(ctx)
+	a.stopBackground = cancel
+
+	a.group.Go("kafka consumer", func() error {
+		if err := a.kafka.StartConsuming(runCtx); err != nil {
+			return err //nolint:wrapcheck // runGroup добавляет имя задачи
+		}
+		if runCtx.Err() == nil {
+			return ErrConsumerStopped
+		}
+		return nil
+	})
+	if a.watcher != nil {
+		a.group.Go("config watcher", func() error { return a.watcher.Run(runCtx) })
+	}
+	if a.gapp != nil {
+		a.group.Go("grpc server", a.gapp.Start)
+	}
 	if a.happ != nil {
-		go a.happ.MustStart()
+		a.group.Go("http server", a.happ.Start)
 	}
+
+	a.ready.Store(true)
+	return nil
 }
 
+// Fatal возвращает канал, в который попадает первая фатальная ошибка фоновой
+// задачи. Получив ее, вызывающий должен выполнить Shutdown.
+func (a *App) Fatal() <-chan error { return a.group.Fatal() }
+
 // Shutdown корректно останавливает запущенные компоненты.
 func (a *App) Shutdown(ctx context.Context) error {
 	select {
@@ -79,41 +186,177 @@ func (a *App) Shutdown(ctx context.Context) error {
 
 	var errs []error
 	a.shutdownOnce.Do(func() {
-		// if a.consumerCancel != nil {
-		// 	a.consumerCancel()
-		// }
+		a.ready.Store(false)
+		if a.stopBackground != nil {
+			a.stopBackground()
+		}
 		if a.happ != nil {
 			if err := a.happ.Shutdown(ctx); err != nil {
 				errs = append(errs, err)
 			}
+			// Веб-сокеты переживают http.Server.Shutdown, их закрываем отдельно.
+			if err := a.ws.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if a.gapp != nil {
+			if err := a.gapp.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// Консюмер должен дообработать текущее сообщение до закрытия соединений.
+		if err := a.group.Wait(ctx); err != nil {
+			errs = append(errs, err)
 		}
 		if err := a.container.StopAll(ctx); err != nil {
 			errs = append(errs, err)
 		}
-		a.wg.Wait()
 	})
 
 	return errors.Join(errs...)
 }
 
-func buildHTTP(cfg *config.HTTPConfig, log *slog.Logger) *happ.HApp {
+// ingestHandler адаптирует сценарий приёма сообщений к обработчику Kafka.
+func ingestHandler(uc *usecase.MessageUseCase) kafka.DeliveryHandler {
+	return func(ctx context.Context, d kafka.Delivery) error {
+		_, err := uc.Ingest(ctx, d.Value, domain.Source{
+			Topic:     d.Topic,
+			Partition: d.Partition,
+			Offset:    d.Offset,
+		})
+		return permanentIfInvalid(err)
+	}
+}
+
+// permanentIfInvalid помечает ошибки валидации как постоянные: такое
+// сообщение сразу уходит в DLQ, не расходуя попытки.
+func permanentIfInvalid(err error) error {
+	if errors.Is(err, domain.ErrInvalidMessage) {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// routeRegistrar регистрирует свои HTTP-маршруты в общем мультиплексоре.
+type routeRegistrar interface {
+	Register(mux *http.ServeMux)
+}
+
+func buildHTTP(
+	cfg *config.HTTPConfig,
+	log *slog.Logger,
+	m *metrics.Metrics,
+	routes ...routeRegistrar,
+) *happ.HApp {
 	mux := http.NewServeMux()
-	return happ.NewHApp(cfg, log, mux)
+	for _, r := range routes {
+		r.Register(mux)
+	}
+	return happ.NewHApp(cfg, log, m.HTTPMiddleware(mux))
+}
+
+// serviceRegistrar регистрирует свои сервисы на gRPC-сервере.
+type serviceRegistrar interface {
+	Register(server *grpc.Server)
 }
 
-func mustInitMongo(cfg *config.Config, log *slog.Logger) *mongodb.MongoDB {
+func buildGRPC(cfg *config.GRPCConfig, log *slog.Logger, services ...serviceRegistrar) *gapp.GApp {
+	return gapp.NewGApp(cfg, log, func(server *grpc.Server) {
+		for _, s := range services {
+			s.Register(server)
+		}
+	})
+}
+
+func initMongo(cfg *config.Config, log *slog.Logger, m *metrics.Metrics) (*mongodb.MongoDB, error) {
 	client, err := mongodb.New(&mongodb.MongoDeps{
-		Cfg:    cfg.MongoConfig,
-		Logger: log,
+		Cfg:     cfg.MongoConfig,
+		Logger:  log,
+		Metrics: m,
 	})
 	if err != nil {
-		log.Error("failed to initialize MongoDB client", slog.String("error", err.Error()))
-		os.Exit(1)
+		return nil, fmt.Errorf("init mongodb client: %w", err)
+	}
+
+	return client, nil
+}
+
+func initMessageRepository(
+	cfg *config.Config,
+	mongo *mongodb.MongoDB,
+	log *slog.Logger,
+	policies *retry.Registry,
+	breaker *retry.Breaker,
+) *repository.MessageRepository {
+	return repository.NewMessageRepository(&repository.MessageRepositoryDeps{
+		Mongo:   mongo,
+		Cfg:     cfg.MongoConfig,
+		Log:     log,
+		Retry:   policies,
+		Breaker: breaker,
+	})
+}
+
+// initStorageBreaker создает предохранитель записи в MongoDB или возвращает
+// nil, если он выключен в конфигурации.
+func initStorageBreaker(cfg *config.MongoConfig, log *slog.Logger, m *metrics.Metrics) *retry.Breaker {
+	if !cfg.Breaker.Enabled {
+		return nil
 	}
+	return retry.NewBreaker("mongo", cfg.Breaker.Policy(),
+		retry.WithFailureClassifier(mongoretry.Classify),
+		retry.WithStateChange(func(name string, from, to retry.State) {
+			m.BreakerState(name, int(to))
+			log.Warn("circuit breaker state changed",
+				slog.String("breaker", name),
+				slog.String("from", from.String()),
+				slog.String("to", to.String()),
+			)
+		}),
+	)
+}
 
-	return client
+func initKafka(
+	cfg *config.Config,
+	log *slog.Logger,
+	m *metrics.Metrics,
+	policies *retry.Registry,
+	storage *retry.Breaker,
+) *kafka.Kafka {
+	return kafka.NewKafka(&kafka.KafkaDeps{
+		Cfg:            cfg,
+		Log:            log,
+		Metrics:        m,
+		Retry:          policies,
+		StorageBreaker: storage,
+	})
 }
 
-func mustInitKafka(cfg *config.Config, log *slog.Logger) *kafka.Kafka {
-	return kafka.NewKafka(&kafka.KafkaDeps{Cfg: cfg, Log: log})
+// ingestBatchHandler адаптирует пакетный приём сообщений к пакетному обработчику Kafka.
+func ingestBatchHandler(uc *usecase.MessageUseCase) kafka.BatchHandler {
+	return func(ctx context.Context, batch []kafka.Delivery) error {
+		raw := make([]usecase.RawMessage, len(batch))
+		for i, d := range batch {
+			raw[i] = usecase.RawMessage{
+				Data: d.Value,
+				Source: domain.Source{
+					Topic:     d.Topic,
+					Partition: d.Partition,
+					Offset:    d.Offset,
+				},
+			}
+		}
+
+		_, err := uc.IngestBatch(ctx, raw)
+
+		var batchErr *domain.BatchError
+		if errors.As(err, &batchErr) {
+			failed := make(map[int]error, len(batchErr.Errors))
+			for i, itemErr := range batchErr.Errors {
+				failed[i] = permanentIfInvalid(itemErr)
+			}
+			return &kafka.BatchError{Failed: failed}
+		}
+		return err
+	}
 }
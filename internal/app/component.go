@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/metrics"
 	"github.com/devoraq/AVQ_message_store/pkg/retry"
 )
 
@@ -17,48 +22,255 @@ type Component interface {
 	Stop(ctx context.Context) error
 }
 
+// HealthChecker — необязательная возможность компонента сообщать о своём
+// состоянии после запуска. Компоненты без неё считаются исправными.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Dependent — необязательная возможность компонента объявить имена
+// компонентов, без которых он не может работать. Контейнер запускает такой
+// компонент только после успешного запуска всех его зависимостей.
+type Dependent interface {
+	DependsOn() []string
+}
+
 // Container хранит набор компонентов и управляет их жизненным циклом.
 type Container struct {
-	comps []Component
-	log   *slog.Logger
-	cfg   *config.Config
+	comps   []Component
+	deps    map[string][]string
+	log     *slog.Logger
+	retry   *retry.Registry
+	metrics *metrics.Metrics
 }
 
 // NewContainer создаёт пустой контейнер без зарегистрированных компонентов.
-func NewContainer(log *slog.Logger, cfg *config.Config) *Container {
+// Запуск повторяется по политике config.RetryStartup из policies. Метрики могут быть nil.
+func NewContainer(log *slog.Logger, policies *retry.Registry, m *metrics.Metrics) *Container {
 	return &Container{
-		log: log,
-		cfg: cfg,
+		deps:    make(map[string][]string),
+		log:     log,
+		retry:   policies,
+		metrics: m,
 	}
 }
 
 // Add добавляет один или несколько компонентов в контейнер.
 func (c *Container) Add(comp ...Component) { c.comps = append(c.comps, comp...) }
 
-// StartAll последовательно запускает компоненты, накапливая ошибки запуска.
+// AddWithDeps добавляет компонент и объявляет его зависимости по имени —
+// в дополнение к тем, что компонент сообщает сам через Dependent.
+func (c *Container) AddWithDeps(comp Component, deps ...string) {
+	c.Add(comp)
+	c.deps[comp.Name()] = append(c.deps[comp.Name()], deps...)
+}
+
+// StartAll запускает компоненты в порядке зависимостей: каждый стартует, как
+// только запущены все его зависимости, поэтому независимые компоненты
+// поднимаются параллельно. Если зависимость не запустилась, зависимые от неё
+// компоненты не запускаются. Ошибки запуска накапливаются.
 func (c *Container) StartAll(ctx context.Context) error {
-	var errs []error
-	for _, comp := range c.comps {
-		component := comp
+	ordered, deps, err := c.order()
+	if err != nil {
+		return err
+	}
+
+	type startResult struct {
+		done chan struct{}
+		err  error
+	}
+	results := make(map[string]*startResult, len(ordered))
+	for _, comp := range ordered {
+		results[comp.Name()] = &startResult{done: make(chan struct{})}
+	}
+
+	var wg sync.WaitGroup
+	for _, comp := range ordered {
+		res := results[comp.Name()]
 
-		err := retry.Do(ctx, c.cfg, func(ctx context.Context) error {
-			return component.Start(ctx)
-		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(res.done)
 
-		if err != nil {
-			errs = append(errs, fmt.Errorf("%s start failed: %w", component.Name(), err))
+			for _, dep := range deps[comp.Name()] {
+				depRes := results[dep]
+				<-depRes.done
+				if depRes.err != nil {
+					res.err = fmt.Errorf("%s not started: %w: %s", comp.Name(), ErrDependencyFailed, dep)
+					return
+				}
+			}
+			res.err = c.start(ctx, comp)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, comp := range ordered {
+		if err := results[comp.Name()].err; err != nil {
+			errs = append(errs, err)
 		}
 	}
 	return errors.Join(errs...)
 }
 
-// StopAll останавливает компоненты в обратном порядке, обеспечивая корректное завершение зависимостей.
+// start запускает компонент с повторными попытками. Ошибку, помеченную
+// retry.Permanent, компонент возвращает, когда повтор заведомо не поможет.
+func (c *Container) start(ctx context.Context, comp Component) error {
+	err := retry.Do(ctx, c.retry.Policy(config.RetryStartup), func(ctx context.Context) error {
+		return comp.Start(ctx)
+	},
+		retry.WithName(comp.Name()+".start"),
+		retry.WithBudget(c.retry.Budget(config.RetryStartup)),
+		retry.WithObserver(func(a retry.Attempt) { c.metrics.RetryAttempt(a.Operation) }),
+		retry.OnRetry(func(attempt int, delay time.Duration, err error) {
+			c.log.Warn("component start retry",
+				slog.String("component", comp.Name()),
+				slog.Int("attempt", attempt),
+				slog.Duration("delay", delay),
+				slog.Any("error", err),
+			)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("%s start failed: %w", comp.Name(), err)
+	}
+
+	c.log.Debug("component started", slog.String("component", comp.Name()))
+	return nil
+}
+
+// StopAll останавливает компоненты в обратном топологическом порядке: каждый
+// компонент останавливается раньше своих зависимостей.
 func (c *Container) StopAll(ctx context.Context) error {
+	ordered, _, err := c.order()
+	if err != nil {
+		// Граф некорректен — StartAll ничего не запускал, но порядок
+		// регистрации все равно позволяет освободить ресурсы.
+		ordered = c.comps
+	}
+
 	var errs []error
-	for i := len(c.comps) - 1; i >= 0; i-- {
-		if err := c.comps[i].Stop(ctx); err != nil {
-			errs = append(errs, fmt.Errorf("%s stop failed: %w", c.comps[i].Name(), err))
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if err := ordered[i].Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s stop failed: %w", ordered[i].Name(), err))
 		}
 	}
 	return errors.Join(errs...)
 }
+
+// order проверяет граф зависимостей и возвращает компоненты в топологическом
+// порядке (при прочих равных — в порядке регистрации) вместе с зависимостями
+// каждого компонента.
+func (c *Container) order() ([]Component, map[string][]string, error) {
+	byName := make(map[string]Component, len(c.comps))
+	for _, comp := range c.comps {
+		if _, ok := byName[comp.Name()]; ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrDuplicateComponent, comp.Name())
+		}
+		byName[comp.Name()] = comp
+	}
+
+	deps := make(map[string][]string, len(c.comps))
+	dependents := make(map[string][]string, len(c.comps))
+	pending := make(map[string]int, len(c.comps))
+	for _, comp := range c.comps {
+		name := comp.Name()
+		for _, dep := range c.dependencies(comp) {
+			if _, ok := byName[dep]; !ok {
+				return nil, nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, name, dep)
+			}
+			if slices.Contains(deps[name], dep) {
+				continue
+			}
+			deps[name] = append(deps[name], dep)
+			dependents[dep] = append(dependents[dep], name)
+			pending[name]++
+		}
+	}
+
+	ordered := make([]Component, 0, len(c.comps))
+	placed := make(map[string]bool, len(c.comps))
+	for len(ordered) < len(c.comps) {
+		progressed := false
+		for _, comp := range c.comps {
+			name := comp.Name()
+			if placed[name] || pending[name] > 0 {
+				continue
+			}
+			placed[name] = true
+			ordered = append(ordered, comp)
+			progressed = true
+			for _, d := range dependents[name] {
+				pending[d]--
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for _, comp := range c.comps {
+				if !placed[comp.Name()] {
+					cycle = append(cycle, comp.Name())
+				}
+			}
+			return nil, nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, deps, nil
+}
+
+// dependencies объединяет зависимости, объявленные при регистрации и самим компонентом.
+func (c *Container) dependencies(comp Component) []string {
+	deps := slices.Clone(c.deps[comp.Name()])
+	if d, ok := comp.(Dependent); ok {
+		deps = append(deps, d.DependsOn()...)
+	}
+	return deps
+}
+
+// ComponentHealth описывает результат проверки одного компонента.
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Статусы проверки компонента.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Health параллельно опрашивает компоненты, реализующие HealthChecker, и
+// возвращает их статусы в порядке регистрации. Второе значение равно true,
+// если все компоненты исправны.
+func (c *Container) Health(ctx context.Context) ([]ComponentHealth, bool) {
+	report := make([]ComponentHealth, len(c.comps))
+	var wg sync.WaitGroup
+	for i, comp := range c.comps {
+		report[i] = ComponentHealth{Name: comp.Name(), Status: StatusUp}
+
+		checker, ok := comp.(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, checker HealthChecker) {
+			defer wg.Done()
+			if err := checker.HealthCheck(ctx); err != nil {
+				report[i].Status = StatusDown
+				report[i].Error = err.Error()
+			}
+		}(i, checker)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, h := range report {
+		if h.Status != StatusUp {
+			healthy = false
+		}
+	}
+	return report, healthy
+}
@@ -0,0 +1,18 @@
+package app
+
+import "errors"
+
+var (
+	// ErrDuplicateComponent означает, что в контейнере два компонента с одним именем.
+	ErrDuplicateComponent = errors.New("app: duplicate component name")
+	// ErrUnknownDependency означает зависимость от незарегистрированного компонента.
+	ErrUnknownDependency = errors.New("app: unknown component dependency")
+	// ErrDependencyCycle означает циклическую зависимость между компонентами.
+	ErrDependencyCycle = errors.New("app: component dependency cycle")
+	// ErrDependencyFailed означает, что компонент не запускался из-за сбоя зависимости.
+	ErrDependencyFailed = errors.New("app: component dependency failed")
+	// ErrConsumerStopped означает, что консюмер Kafka завершился без команды на остановку.
+	ErrConsumerStopped = errors.New("app: kafka consumer stopped unexpectedly")
+	// ErrTaskPanicked означает панику в фоновой задаче приложения.
+	ErrTaskPanicked = errors.New("app: background task panicked")
+)
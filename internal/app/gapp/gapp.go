@@ -0,0 +1,115 @@
+// Package gapp отвечает за запуск gRPC-слоя приложения.
+package gapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+// GApp управляет жизненным циклом gRPC-сервера.
+type GApp struct {
+	log    *slog.Logger
+	server *grpc.Server
+	health *health.Server
+	cfg    *config.GRPCConfig
+}
+
+// NewGApp создает обертку gRPC-приложения. register регистрирует сервисы на сервере;
+// стандартный сервис grpc.health.v1.Health регистрируется автоматически.
+func NewGApp(cfg *config.GRPCConfig, log *slog.Logger, register func(s *grpc.Server)) *GApp {
+	server := grpc.NewServer(
+		grpc.ConnectionTimeout(cfg.ConnectionTimeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: cfg.MaxConnectionIdle,
+			Time:              cfg.KeepaliveTime,
+			Timeout:           cfg.KeepaliveTimeout,
+		}),
+		grpc.ChainUnaryInterceptor(timeoutInterceptor(cfg)),
+	)
+	register(server)
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthSrv)
+
+	return &GApp{
+		log:    log,
+		server: server,
+		health: healthSrv,
+		cfg:    cfg,
+	}
+}
+
+// Start запускает обработку gRPC-запросов.
+func (ga *GApp) Start() error {
+	const op = "GApp.Start"
+	log := ga.log.With("op", op)
+
+	log.Info(
+		"gRPC server is starting",
+		slog.String("address", ga.cfg.Addr),
+	)
+
+	lis, err := net.Listen("tcp", ga.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("grpc server listen: %w", err)
+	}
+
+	for name := range ga.server.GetServiceInfo() {
+		ga.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	ga.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	if err := ga.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server serve: %w", err)
+	}
+
+	return nil
+}
+
+// Shutdown корректно останавливает gRPC-сервер, дожидаясь завершения активных
+// вызовов. Если ctx истекает раньше, соединения закрываются принудительно.
+func (ga *GApp) Shutdown(ctx context.Context) error {
+	// Клиенты health-check сразу узнают об остановке и перестают слать запросы.
+	ga.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		ga.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		ga.server.Stop()
+		return fmt.Errorf("grpc server shutdown: %w", ctx.Err())
+	}
+}
+
+// timeoutInterceptor ограничивает длительность унарных вызовов RequestTimeout.
+func timeoutInterceptor(cfg *config.GRPCConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if cfg.RequestTimeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, cfg.RequestTimeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// runGroup запускает фоновые задачи приложения и передает первую фатальную
+// ошибку любой из них (включая панику) в канал fatal, чтобы владелец
+// инициировал упорядоченную остановку.
+type runGroup struct {
+	wg    sync.WaitGroup
+	once  sync.Once
+	fatal chan error
+}
+
+func newRunGroup() *runGroup {
+	return &runGroup{fatal: make(chan error, 1)}
+}
+
+// Go запускает задачу fn под именем name. Возврат ошибки или паника задачи
+// считаются фатальными; nil означает штатное завершение.
+func (g *runGroup) Go(name string, fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				g.fail(fmt.Errorf("%s: %w: %v", name, ErrTaskPanicked, r))
+			}
+		}()
+
+		if err := fn(); err != nil {
+			g.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+}
+
+// fail публикует только первую ошибку: остальные — как правило, следствие
+// уже начавшейся остановки.
+func (g *runGroup) fail(err error) {
+	g.once.Do(func() { g.fatal <- err })
+}
+
+// Fatal возвращает канал, в который попадает первая фатальная ошибка.
+func (g *runGroup) Fatal() <-chan error { return g.fatal }
+
+// Wait ожидает завершения всех задач, но не дольше, чем позволяет ctx.
+func (g *runGroup) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait background workers: %w", ctx.Err())
+	}
+}
@@ -18,10 +18,10 @@ type HApp struct {
 }
 
 // NewHApp создает обертку HTTP-приложения.
-func NewHApp(cfg *config.HTTPConfig, log *slog.Logger, mux *http.ServeMux) *HApp {
+func NewHApp(cfg *config.HTTPConfig, log *slog.Logger, handler http.Handler) *HApp {
 	server := &http.Server{
 		Addr:              cfg.Addr,
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
 		ReadTimeout:       cfg.ReadTimeout,
 		WriteTimeout:      cfg.WriteTimeout,
@@ -52,14 +52,6 @@ func (ha *HApp) Start() error {
 	return nil
 }
 
-// MustStart запускает сервер и паникует при ошибке.
-func (ha *HApp) MustStart() {
-	if err := ha.Start(); err != nil {
-		ha.log.Error("HTTP server failed", "err", err)
-		panic(err)
-	}
-}
-
 // Shutdown корректно останавливает HTTP-сервер.
 func (ha *HApp) Shutdown(ctx context.Context) error {
 	if err := ha.server.Shutdown(ctx); err != nil {
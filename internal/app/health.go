@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheckTimeout ограничивает суммарное время опроса компонентов в /readyz.
+const healthCheckTimeout = 2 * time.Second
+
+// healthHandler обслуживает пробы оркестратора:
+//   - /healthz (liveness) отвечает 200, пока процесс способен обслуживать HTTP;
+//   - /readyz (readiness) опрашивает компоненты и отвечает 503, если хотя бы
+//     один из них неисправен, а также до завершения запуска и во время остановки.
+type healthHandler struct {
+	container *Container
+	ready     *atomic.Bool
+}
+
+type healthResponse struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components,omitempty"`
+}
+
+// Register регистрирует маршруты проб в переданном мультиплексоре.
+func (h *healthHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.liveness)
+	mux.HandleFunc("GET /readyz", h.readiness)
+}
+
+func (h *healthHandler) liveness(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: StatusUp})
+}
+
+func (h *healthHandler) readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, healthResponse{Status: StatusDown})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	components, healthy := h.container.Health(ctx)
+	resp := healthResponse{Status: StatusUp, Components: components}
+	status := http.StatusOK
+	if !healthy {
+		resp.Status = StatusDown
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, resp)
+}
+
+func writeHealth(w http.ResponseWriter, status int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMessageNotFound сообщает, что сообщение с указанным идентификатором отсутствует.
+	ErrMessageNotFound = errors.New("domain: message not found")
+	// ErrInvalidMessage сигнализирует о нарушении инвариантов сообщения.
+	ErrInvalidMessage = errors.New("domain: invalid message")
+	// ErrDuplicateMessage означает, что сообщение с таким идемпотентным ключом уже сохранено.
+	ErrDuplicateMessage = errors.New("domain: duplicate message")
+	// ErrInvalidQuery сообщает о некорректных параметрах выборки (курсор, лимит).
+	ErrInvalidQuery = errors.New("domain: invalid list query")
+)
+
+// BatchError описывает частичный отказ пакетной операции. Ключи Errors —
+// индексы элементов входного среза, обработка которых не удалась;
+// остальные элементы обработаны успешно.
+type BatchError struct {
+	Errors map[int]error
+}
+
+// Error возвращает краткое описание частичного отказа.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("domain: %d batch item(s) failed", len(e.Errors))
+}
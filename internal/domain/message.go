@@ -0,0 +1,127 @@
+// Package domain содержит предметные сущности сервиса хранения сообщений
+// и интерфейсы, не зависящие от инфраструктуры.
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Message описывает сообщение чата, сохраняемое сервисом.
+type Message struct {
+	// ID — идентификатор сообщения в хранилище. Заполняется репозиторием при сохранении.
+	ID string
+	// Key — идемпотентный ключ: идентификатор, присвоенный продюсером, либо
+	// координаты записи в Kafka. Повторное сохранение с тем же ключом отклоняется.
+	Key string
+	// ChatID — идентификатор чата (беседы), к которому относится сообщение.
+	ChatID string
+	// SenderID — идентификатор отправителя.
+	SenderID string
+	// Payload — тело сообщения в исходном виде.
+	Payload []byte
+	// ContentType описывает формат Payload (например, text/plain).
+	ContentType string
+	// CreatedAt — время создания сообщения на стороне отправителя.
+	CreatedAt time.Time
+	// ReceivedAt — время получения сообщения сервисом.
+	ReceivedAt time.Time
+	// Source хранит координаты исходной записи в Kafka.
+	Source Source
+}
+
+// LogValue реализует slog.LogValuer: тело сообщения в лог не попадает,
+// выводится только его размер.
+func (m Message) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("id", m.ID),
+		slog.String("key", m.Key),
+		slog.String("chat_id", m.ChatID),
+		slog.String("sender_id", m.SenderID),
+		slog.String("content_type", m.ContentType),
+		slog.Int("payload_size", len(m.Payload)),
+		slog.Time("created_at", m.CreatedAt),
+	}
+	if !m.Source.IsZero() {
+		attrs = append(attrs, slog.Group("source",
+			slog.String("topic", m.Source.Topic),
+			slog.Int("partition", m.Source.Partition),
+			slog.Int64("offset", m.Source.Offset),
+		))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Source описывает координаты сообщения в топике Kafka.
+type Source struct {
+	Topic     string
+	Partition int
+	Offset    int64
+}
+
+// IsZero сообщает, что координаты не заданы (сообщение получено не из Kafka).
+func (s Source) IsZero() bool { return s.Topic == "" }
+
+// MessageKey строит детерминированный идемпотентный ключ сообщения:
+// идентификатор продюсера, если он задан, иначе координаты в Kafka.
+// Возвращает пустую строку, если нет ни того, ни другого.
+func MessageKey(producerID string, src Source) string {
+	switch {
+	case producerID != "":
+		return "id:" + producerID
+	case !src.IsZero():
+		return fmt.Sprintf("kafka:%s/%d/%d", src.Topic, src.Partition, src.Offset)
+	default:
+		return ""
+	}
+}
+
+// Validate проверяет обязательные поля сообщения.
+func (m *Message) Validate() error {
+	switch {
+	case m.ChatID == "":
+		return fmt.Errorf("%w: chat id is required", ErrInvalidMessage)
+	case m.SenderID == "":
+		return fmt.Errorf("%w: sender id is required", ErrInvalidMessage)
+	case len(m.Payload) == 0:
+		return fmt.Errorf("%w: payload is required", ErrInvalidMessage)
+	case m.CreatedAt.IsZero():
+		return fmt.Errorf("%w: created at is required", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// Cursor задает позицию в ленте сообщений чата. Сообщения упорядочены
+// по паре (CreatedAt, ID), что делает позицию однозначной.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ListQuery описывает запрос страницы сообщений чата. Если задан Before,
+// возвращаются сообщения старше курсора, если After — новее. Результат всегда
+// упорядочен от новых к старым.
+type ListQuery struct {
+	ChatID string
+	Before *Cursor
+	After  *Cursor
+	Limit  int
+}
+
+// MessageRepository описывает хранилище сообщений.
+type MessageRepository interface {
+	// Save сохраняет сообщение и заполняет его идентификатор.
+	// Возвращает ErrDuplicateMessage, если сообщение с тем же Key уже сохранено.
+	Save(ctx context.Context, msg *Message) error
+	// SaveMany сохраняет пачку сообщений независимо друг от друга. При частичном
+	// отказе возвращает *BatchError; дубликаты отмечаются ErrDuplicateMessage.
+	SaveMany(ctx context.Context, msgs []*Message) error
+	// GetByID возвращает сообщение по идентификатору либо ErrMessageNotFound.
+	GetByID(ctx context.Context, id string) (*Message, error)
+	// GetByKey возвращает сообщение по идемпотентному ключу либо ErrMessageNotFound.
+	GetByKey(ctx context.Context, key string) (*Message, error)
+	// ListByChat возвращает страницу сообщений чата согласно запросу.
+	ListByChat(ctx context.Context, q ListQuery) ([]*Message, error)
+}
@@ -4,9 +4,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/logger"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -15,25 +20,107 @@ type Config struct {
 	*AppConfig   `yaml:"app"`
 	*RetryConfig `yaml:"retry"`
 	*HTTPConfig  `yaml:"http"`
+	*GRPCConfig  `yaml:"grpc"`
 	*MongoConfig `yaml:"mongo"`
 	*KafkaConfig `yaml:"kafka"`
+
+	*TracingConfig `yaml:"tracing"`
+	*LogConfig     `yaml:"log"`
+
+	// warnings — замечания загрузки, не мешающие работе (см. Warnings).
+	warnings []string
 }
 
+// Warnings возвращает замечания, найденные при загрузке, например об
+// устаревших переменных окружения. Их стоит вывести в лог.
+func (c *Config) Warnings() []string { return c.warnings }
+
 // AppConfig описывает флаги включения подсистем приложения.
 type AppConfig struct {
-	IsHTTPEnabled   bool          `yaml:"http_enable" env:"APP_HTTP_ENABLED" env-default:"true"`
-	IsGrpcEnabled   bool          `yaml:"grpc_enable" env:"APP_GRPC_ENABLED" env-default:"false"`
+	IsHTTPEnabled   bool          `yaml:"http_enable" env-alias:"APP_HTTP_ENABLED" env-default:"true"`
+	IsGrpcEnabled   bool          `yaml:"grpc_enable" env-alias:"APP_GRPC_ENABLED" env-default:"false"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	// ConfigWatchInterval — период проверки файлов конфигурации на изменения.
+	// 0 отключает слежение за файлами; перечитать конфигурацию по-прежнему
+	// можно сигналом SIGHUP.
+	ConfigWatchInterval time.Duration `yaml:"config_watch_interval"`
+}
+
+// LogConfig задает параметры логирования.
+type LogConfig struct {
+	// Level — минимальный уровень: debug, info, warn или error.
+	Level string `yaml:"level" env-default:"debug"`
+	// Format — формат записей: pretty, json или logfmt; пустой означает pretty.
+	Format string `yaml:"format" env-default:"pretty"`
+	// Output — stdout, stderr или путь к файлу; пустой означает stdout.
+	Output string `yaml:"output" env-default:"stdout"`
+	// AddSource добавляет к записям файл и строку вызова.
+	AddSource bool `yaml:"add_source"`
+	// Redact — дополнительные шаблоны ключей (path.Match, без учета регистра),
+	// значения которых скрываются в логах, к logger.DefaultRedactKeys.
+	Redact []string `yaml:"redact"`
+	// Sampling ограничивает частоту одинаковых записей (уровень, сообщение, op).
+	Sampling SamplingConfig `yaml:"sampling"`
+}
+
+// SamplingConfig задает ограничение частоты одинаковых записей логов:
+// за Interval выводится не больше Burst таких записей, об остальных
+// сообщает сводка. Нулевой Interval отключает ограничение.
+type SamplingConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Burst    int           `yaml:"burst"`
+}
+
+// SlogLevel возвращает уровень логирования; пустой уровень означает debug.
+func (c *LogConfig) SlogLevel() (slog.Level, error) {
+	if c == nil || c.Level == "" {
+		return slog.LevelDebug, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return 0, fmt.Errorf("parse log level: %w", err)
+	}
+	return level, nil
 }
 
 // MongoConfig хранит параметры подключения к MongoDB.
 type MongoConfig struct {
 	Addr           string        `yaml:"addr"`
 	Username       string        `yaml:"username"`
-	Password       string        `yaml:"password"`
+	Password       logger.Secret `yaml:"password"`
 	DB             string        `yaml:"db_name"`
 	ConnectTimeout time.Duration `yaml:"connect_timeout"`
 	MaxPoolSize    uint64        `yaml:"max_pool_size"`
+	Collection     string        `yaml:"messages_collection" env-default:"messages"`
+	// WriteRetry — политика повторов записи при временных сбоях MongoDB.
+	WriteRetry RetryConfig `yaml:"write_retry"`
+	// ReadRetry — страхующие попытки чтения (hedge); повторы чтения не выполняются.
+	ReadRetry RetryConfig `yaml:"read_retry"`
+	// Breaker — предохранитель записи: пока он разомкнут, консюмер Kafka
+	// не читает новые сообщения.
+	Breaker BreakerConfig `yaml:"breaker"`
+}
+
+// BreakerConfig задает параметры предохранителя (см. retry.BreakerPolicy).
+// Нулевые значения заменяются значениями по умолчанию.
+type BreakerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Window           time.Duration `yaml:"window"`
+	MinRequests      int           `yaml:"min_requests"`
+	FailureRatio     float64       `yaml:"failure_ratio"`
+	Cooldown         time.Duration `yaml:"cooldown"`
+	HalfOpenRequests int           `yaml:"half_open_requests"`
+}
+
+// Policy возвращает параметры предохранителя для retry.NewBreaker.
+func (c BreakerConfig) Policy() retry.BreakerPolicy {
+	return retry.BreakerPolicy{
+		Window:           c.Window,
+		MinRequests:      c.MinRequests,
+		FailureRatio:     c.FailureRatio,
+		Cooldown:         c.Cooldown,
+		HalfOpenRequests: c.HalfOpenRequests,
+	}
 }
 
 // KafkaConfig содержит настройки брокера Kafka, необходимые для инициализации
@@ -45,6 +132,31 @@ type KafkaConfig struct {
 	Network       string      `yaml:"network"`
 	FetchBackoff  RetryConfig `yaml:"fetchBackoff"`
 	CommitBackoff RetryConfig `yaml:"commitBackoff"`
+	DLQ           DLQConfig   `yaml:"dlq"`
+	// PartitionWorkers ограничивает число горутин, параллельно обрабатывающих
+	// партиции. Значение 1 и меньше включает последовательную обработку.
+	PartitionWorkers int         `yaml:"partition-workers" env-default:"1"`
+	Batch            BatchConfig `yaml:"batch"`
+	// MaxLag — допустимое отставание консюмера (в сообщениях), после которого
+	// проверка готовности считает Kafka неисправной. 0 отключает проверку.
+	MaxLag int64 `yaml:"max-lag"`
+}
+
+// BatchConfig включает пакетное чтение: сообщения накапливаются, пока их не
+// станет Size или не истечет Timeout, и передаются обработчику одной пачкой.
+type BatchConfig struct {
+	Enabled bool          `yaml:"enabled" env-default:"false"`
+	Size    int           `yaml:"size" env-default:"100"`
+	Timeout time.Duration `yaml:"timeout" env-default:"200ms"`
+}
+
+// DLQConfig задает параметры dead-letter очереди: после MaxAttempts
+// неудачных локальных попыток обработки сообщение публикуется в Topic,
+// а оффсет исходного сообщения фиксируется.
+type DLQConfig struct {
+	Enabled     bool   `yaml:"enabled" env-default:"false"`
+	Topic       string `yaml:"topic"`
+	MaxAttempts int    `yaml:"max-attempts" env-default:"3"`
 }
 
 // RetryConfig определяет параметры для механизма повторных попыток.
@@ -54,35 +166,193 @@ type RetryConfig struct {
 	Max      time.Duration `yaml:"max"      env-default:"30s"`
 	Factor   float64       `yaml:"factor"   env-default:"2.0"`
 	Jitter   bool          `yaml:"jitter"   env-default:"true"`
+	// Budget ограничивает долю повторов среди вызовов политики.
+	Budget RetryBudgetConfig `yaml:"budget"`
+	// Hedge включает страхующие попытки для идемпотентных операций.
+	Hedge HedgeConfig `yaml:"hedge"`
+}
+
+// RetryBudgetConfig задает бюджет повторов (см. retry.BudgetPolicy).
+// Нулевой ratio отключает бюджет.
+type RetryBudgetConfig struct {
+	Ratio        float64 `yaml:"ratio"`
+	MinPerSecond float64 `yaml:"min_per_second"`
+	Burst        float64 `yaml:"burst"`
+}
+
+// HedgeConfig задает страхующие попытки (см. retry.HedgePolicy).
+// Нулевая задержка отключает их.
+type HedgeConfig struct {
+	Delay    time.Duration `yaml:"delay"`
+	Attempts int           `yaml:"attempts"`
+}
+
+// Policy возвращает политику повторов с параметрами раздела.
+func (c RetryConfig) Policy() retry.Policy {
+	return retry.Policy{
+		Attempts: c.Attempts,
+		Initial:  c.Initial,
+		Max:      c.Max,
+		Factor:   c.Factor,
+		Jitter:   c.Jitter,
+		Budget: retry.BudgetPolicy{
+			Ratio:        c.Budget.Ratio,
+			MinPerSecond: c.Budget.MinPerSecond,
+			Burst:        c.Budget.Burst,
+		},
+		Hedge: retry.HedgePolicy{
+			Delay:    c.Hedge.Delay,
+			Attempts: c.Hedge.Attempts,
+		},
+	}
+}
+
+// Имена политик повторов в реестре (см. RetryPolicies).
+const (
+	// RetryStartup — запуск компонентов; раздел retry.
+	RetryStartup = "startup"
+	// RetryKafkaFetch — паузы после ошибок чтения из Kafka; kafka.fetchBackoff.
+	RetryKafkaFetch = "kafka.fetch"
+	// RetryKafkaCommit — коммит оффсетов; kafka.commitBackoff.
+	RetryKafkaCommit = "kafka.commit"
+	// RetryKafkaHandle — паузы между повторами обработчика сообщения. Отдельного
+	// раздела нет: используется политика по умолчанию (раздел retry), а число
+	// попыток задает kafka.dlq.max-attempts.
+	RetryKafkaHandle = "kafka.handle"
+	// RetryMongoWrite — запись в MongoDB; mongo.write_retry.
+	RetryMongoWrite = "mongo.write"
+	// RetryMongoRead — чтение из MongoDB; mongo.read_retry.
+	RetryMongoRead = "mongo.read"
+)
+
+// RetryPolicyPaths перечисляет разделы, из которых строятся политики повторов.
+var RetryPolicyPaths = []string{
+	"retry",
+	"kafka.fetchBackoff",
+	"kafka.commitBackoff",
+	"mongo.write_retry",
+	"mongo.read_retry",
+}
+
+// RetryPolicies возвращает политику по умолчанию (раздел retry) и именованные
+// политики для NewRegistry/Update реестра retry.Registry.
+func (c *Config) RetryPolicies() (retry.Policy, map[string]retry.Policy) {
+	var fallback retry.Policy
+	if c.RetryConfig != nil {
+		fallback = c.RetryConfig.Policy()
+	}
+
+	named := map[string]retry.Policy{RetryStartup: fallback}
+	if c.KafkaConfig != nil {
+		named[RetryKafkaFetch] = c.FetchBackoff.Policy()
+		named[RetryKafkaCommit] = c.CommitBackoff.Policy()
+	}
+	if c.MongoConfig != nil {
+		named[RetryMongoWrite] = c.WriteRetry.Policy()
+		named[RetryMongoRead] = c.ReadRetry.Policy()
+	}
+	return fallback, named
 }
 
 // HTTPConfig задает настройки HTTP-сервера.
 type HTTPConfig struct {
-	Addr              string        `yaml:"addr" env:"HTTP_ADDR"`
-	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
-	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
-	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
-	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	Addr              string          `yaml:"addr" env-alias:"HTTP_ADDR"`
+	ReadHeaderTimeout time.Duration   `yaml:"read_header_timeout" env-alias:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration   `yaml:"read_timeout" env-alias:"HTTP_READ_TIMEOUT"`
+	WriteTimeout      time.Duration   `yaml:"write_timeout" env-alias:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration   `yaml:"idle_timeout" env-alias:"HTTP_IDLE_TIMEOUT"`
+	WebSocket         WebSocketConfig `yaml:"websocket"`
+}
+
+// WebSocketConfig задает параметры приёма сообщений по веб-сокету.
+// MaxInFlight ограничивает число сообщений одного соединения, которые
+// обрабатываются одновременно: пока окно заполнено, новые кадры не читаются.
+type WebSocketConfig struct {
+	MaxInFlight    int           `yaml:"max_in_flight" env-alias:"WS_MAX_IN_FLIGHT" env-default:"32"`
+	MaxMessageSize int64         `yaml:"max_message_size" env-alias:"WS_MAX_MESSAGE_SIZE" env-default:"65536"`
+	ProcessTimeout time.Duration `yaml:"process_timeout" env-alias:"WS_PROCESS_TIMEOUT" env-default:"10s"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env-alias:"WS_WRITE_TIMEOUT" env-default:"5s"`
+	PingInterval   time.Duration `yaml:"ping_interval" env-alias:"WS_PING_INTERVAL" env-default:"30s"`
+}
+
+// GRPCConfig задает настройки gRPC-сервера.
+type GRPCConfig struct {
+	Addr              string        `yaml:"addr" env-alias:"GRPC_ADDR"`
+	ConnectionTimeout time.Duration `yaml:"connection_timeout" env-alias:"GRPC_CONNECTION_TIMEOUT"`
+	RequestTimeout    time.Duration `yaml:"request_timeout" env-alias:"GRPC_REQUEST_TIMEOUT"`
+	MaxConnectionIdle time.Duration `yaml:"max_connection_idle" env-alias:"GRPC_MAX_CONNECTION_IDLE"`
+	KeepaliveTime     time.Duration `yaml:"keepalive_time" env-alias:"GRPC_KEEPALIVE_TIME"`
+	KeepaliveTimeout  time.Duration `yaml:"keepalive_timeout" env-alias:"GRPC_KEEPALIVE_TIMEOUT"`
+}
+
+// TracingConfig задает экспорт трассировки OpenTelemetry.
+// Exporter принимает значения "none" (трассы не экспортируются, но контекст
+// по-прежнему передается дальше), "stdout" (для локальной отладки) и "otlp".
+// SampleRatio — доля сэмплируемых корневых трасс; 0 означает «все».
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env-alias:"TRACING_EXPORTER" env-default:"none"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env-alias:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env-alias:"TRACING_OTLP_INSECURE"`
+	ServiceName  string  `yaml:"service_name" env-alias:"TRACING_SERVICE_NAME" env-default:"message-store"`
+	SampleRatio  float64 `yaml:"sample_ratio" env-alias:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// LoadOptions задает источники конфигурации.
+type LoadOptions struct {
+	// Path — путь к базовому YAML-файлу.
+	Path string
+	// Profile — имя окружения (например, "prod"). Если задано, поверх базового
+	// файла читается файл профиля рядом с ним: config.yaml → config.prod.yaml.
+	Profile string
 }
 
-// LoadConfig считывает конфигурацию из YAML-файла по указанному пути либо паникует при ошибке.
-func LoadConfig(path string) *Config {
+// LoadConfig собирает конфигурацию слоями: базовый YAML-файл, файл профиля,
+// переменные окружения (см. EnvPrefix). Затем конфигурация проверяется
+// (см. Validate); ошибка проверки — *ValidationError.
+func LoadConfig(opts LoadOptions) (*Config, error) {
+	var cfg Config
+	if err := readFile(opts.Path, &cfg); err != nil {
+		return nil, err
+	}
+	if opts.Profile != "" {
+		// Значения профиля накладываются на уже прочитанные: ключи,
+		// отсутствующие в файле профиля, сохраняют базовые значения.
+		if err := readFile(ProfilePath(opts.Path, opts.Profile), &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// ProfilePath возвращает путь к файлу профиля для базового файла path.
+func ProfilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+func readFile(path string, cfg *Config) error {
 	stat, err := os.Stat(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			panic("config not found")
+			return fmt.Errorf("%w: %s", ErrConfigNotFound, path)
 		}
-		panic(fmt.Errorf("cannot access config: %w", err))
+		return fmt.Errorf("%w: %w", ErrReadConfig, err)
 	}
 
 	if stat.Size() == 0 {
-		panic("config is empty")
+		return fmt.Errorf("%w: %s", ErrConfigEmpty, path)
 	}
 
-	var cfg Config
-	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
-		panic(err)
+	if err := cleanenv.ReadConfig(path, cfg); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrReadConfig, path, err)
 	}
-
-	return &cfg
+	return nil
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Change описывает изменившийся параметр. Path — YAML-путь, например
+// "kafka.batch.size".
+type Change struct {
+	Path string
+	Old  any
+	New  any
+}
+
+// Diff сравнивает две конфигурации по параметрам и возвращает изменения в
+// порядке объявления полей. Отсутствующий раздел сравнивается как пустой.
+func Diff(prev, next *Config) []Change {
+	var changes []Change
+	diffStruct(reflect.ValueOf(prev).Elem(), reflect.ValueOf(next).Elem(), nil, &changes)
+	return changes
+}
+
+// Matches сообщает, относится ли путь изменения к параметру или разделу
+// prefix: "kafka.batch" покрывает "kafka.batch.size".
+func (c Change) Matches(prefix string) bool {
+	return c.Path == prefix || strings.HasPrefix(c.Path, prefix+".")
+}
+
+func diffStruct(prev, next reflect.Value, path []string, changes *[]Change) {
+	t := prev.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		diffValue(prev.Field(i), next.Field(i), append(path[:len(path):len(path)], name), changes)
+	}
+}
+
+func diffValue(prev, next reflect.Value, path []string, changes *[]Change) {
+	switch {
+	case prev.Kind() == reflect.Pointer && prev.Type().Elem().Kind() == reflect.Struct:
+		diffStruct(derefOrZero(prev), derefOrZero(next), path, changes)
+	case prev.Kind() == reflect.Struct && prev.Type() != durationType:
+		diffStruct(prev, next, path, changes)
+	default:
+		if !reflect.DeepEqual(prev.Interface(), next.Interface()) {
+			*changes = append(*changes, Change{
+				Path: strings.Join(path, "."),
+				Old:  prev.Interface(),
+				New:  next.Interface(),
+			})
+		}
+	}
+}
+
+func derefOrZero(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.New(v.Type().Elem()).Elem()
+	}
+	return v.Elem()
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// LogValue позволяет логировать конфигурацию целиком: разделы выводятся
+// группами с YAML-именами, секреты (logger.Secret) скрыты.
+func (c *Config) LogValue() slog.Value {
+	if c == nil {
+		return slog.StringValue("<nil>")
+	}
+	return slog.GroupValue(structAttrs(reflect.ValueOf(c).Elem())...)
+}
+
+// String печатает конфигурацию по строке на параметр в виде "путь: значение".
+// Секреты скрыты, поэтому результат можно выводить в лог и консоль.
+func (c *Config) String() string {
+	if c == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	dumpStruct(reflect.ValueOf(c).Elem(), nil, &b)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// GoString скрывает секреты и в формате %#v.
+func (c *Config) GoString() string { return c.String() }
+
+func structAttrs(v reflect.Value) []slog.Attr {
+	t := v.Type()
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct:
+			if !fv.IsNil() {
+				attrs = append(attrs, slog.Attr{Key: name, Value: slog.GroupValue(structAttrs(fv.Elem())...)})
+			}
+		case fv.Kind() == reflect.Struct && fv.Type() != durationType:
+			attrs = append(attrs, slog.Attr{Key: name, Value: slog.GroupValue(structAttrs(fv)...)})
+		default:
+			attrs = append(attrs, slog.Any(name, fv.Interface()))
+		}
+	}
+	return attrs
+}
+
+func dumpStruct(v reflect.Value, path []string, b *strings.Builder) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct:
+			if !fv.IsNil() {
+				dumpStruct(fv.Elem(), fieldPath, b)
+			}
+		case fv.Kind() == reflect.Struct && fv.Type() != durationType:
+			dumpStruct(fv, fieldPath, b)
+		default:
+			fmt.Fprintf(b, "%s: %v\n", strings.Join(fieldPath, "."), fv.Interface())
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix — общий префикс переменных окружения, переопределяющих параметры.
+//
+// Имя переменной выводится из YAML-пути параметра: сегменты переводятся в
+// верхний регистр, camelCase и дефисы разбиваются подчеркиваниями. Например,
+// kafka.dlq.max-attempts → MSG_STORE_KAFKA_DLQ_MAX_ATTEMPTS,
+// kafka.fetchBackoff.initial → MSG_STORE_KAFKA_FETCH_BACKOFF_INITIAL.
+//
+// Вместо значения можно указать путь к файлу в переменной с суффиксом
+// EnvFileSuffix (MSG_STORE_MONGO_PASSWORD_FILE) — так передаются секреты,
+// смонтированные в контейнер.
+//
+// Параметры, которые раньше читались из переменных без префикса (HTTP_ADDR,
+// GRPC_ADDR, TRACING_EXPORTER и т.п.), по-прежнему принимают их: имя задано
+// тегом EnvAliasTag. Такая переменная применяется, только если не задана
+// переменная с префиксом, и порождает предупреждение (см. Config.Warnings).
+const EnvPrefix = "MSG_STORE_"
+
+// EnvAliasTag — тег поля с устаревшим именем переменной окружения.
+const EnvAliasTag = "env-alias"
+
+// EnvFileSuffix — суффикс переменной, содержащей путь к файлу со значением.
+const EnvFileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv переопределяет параметры cfg значениями из переменных окружения.
+// Разделы, отсутствующие в YAML, создаются, только если для них задана хотя
+// бы одна переменная.
+// Предупреждения об устаревших переменных добавляются в cfg.warnings.
+func applyEnv(cfg *Config) error {
+	o := &envOverlay{}
+	_, err := o.overlayStruct(reflect.ValueOf(cfg).Elem(), nil)
+	cfg.warnings = append(cfg.warnings, o.warnings...)
+	return err
+}
+
+// envOverlay накладывает переменные окружения на конфигурацию и собирает
+// предупреждения об устаревших именах.
+type envOverlay struct {
+	warnings []string
+}
+
+// overlayStruct применяет переменные к полям структуры v. Возвращает true,
+// если было изменено хотя бы одно поле.
+func (o *envOverlay) overlayStruct(v reflect.Value, path []string) (bool, error) {
+	changed := false
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+
+		ok, err := o.overlayValue(v.Field(i), fieldPath, field.Tag.Get(EnvAliasTag))
+		if err != nil {
+			return false, err
+		}
+		changed = changed || ok
+	}
+	return changed, nil
+}
+
+func (o *envOverlay) overlayValue(v reflect.Value, path []string, alias string) (bool, error) {
+	switch {
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct:
+		if !v.IsNil() {
+			return o.overlayStruct(v.Elem(), path)
+		}
+		section := reflect.New(v.Type().Elem())
+		changed, err := o.overlayStruct(section.Elem(), path)
+		if changed {
+			v.Set(section)
+		}
+		return changed, err
+	case v.Kind() == reflect.Struct && v.Type() != durationType:
+		return o.overlayStruct(v, path)
+	}
+
+	name := envName(path)
+	raw, ok, err := lookupEnv(name)
+	if err != nil {
+		return false, err
+	}
+	if alias != "" {
+		if _, legacy := os.LookupEnv(alias); legacy {
+			if ok {
+				o.warn("%s is ignored because %s is set", alias, name)
+			} else {
+				o.warn("%s is deprecated, use %s", alias, name)
+				raw, ok, name = os.Getenv(alias), true, alias
+			}
+		}
+	}
+	if !ok {
+		return false, nil
+	}
+	if err := setValue(v, raw); err != nil {
+		return false, fmt.Errorf("%w: %s (%s): %w", ErrReadConfig, name, strings.Join(path, "."), err)
+	}
+	return true, nil
+}
+
+func (o *envOverlay) warn(format string, args ...any) {
+	o.warnings = append(o.warnings, fmt.Sprintf(format, args...))
+}
+
+// lookupEnv читает значение переменной name либо файла из name+EnvFileSuffix.
+// Одновременно задавать обе переменные нельзя.
+func lookupEnv(name string) (string, bool, error) {
+	value, hasValue := os.LookupEnv(name)
+	file, hasFile := os.LookupEnv(name + EnvFileSuffix)
+	switch {
+	case hasValue && hasFile:
+		return "", false, fmt.Errorf("%w: both %s and %s%s are set", ErrReadConfig, name, name, EnvFileSuffix)
+	case hasFile:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: %s%s: %w", ErrReadConfig, name, EnvFileSuffix, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	default:
+		return value, hasValue, nil
+	}
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("parse duration: %w", err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("parse bool: %w", err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse int: %w", err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse uint: %w", err)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse float: %w", err)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		// Элементы списка перечисляются через запятую.
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func envName(path []string) string {
+	parts := make([]string, len(path))
+	for i, segment := range path {
+		parts[i] = envSegment(segment)
+	}
+	return EnvPrefix + strings.Join(parts, "_")
+}
+
+// envSegment переводит сегмент YAML-пути в часть имени переменной:
+// "fetchBackoff" → "FETCH_BACKOFF", "max-lag" → "MAX_LAG".
+func envSegment(segment string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, r := range segment {
+		switch {
+		case r == '-' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r) && prev != 0 && prev != '_' && !unicode.IsUpper(prev):
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{path: []string{"app", "http_enable"}, want: "MSG_STORE_APP_HTTP_ENABLE"},
+		{path: []string{"kafka", "dlq", "max-attempts"}, want: "MSG_STORE_KAFKA_DLQ_MAX_ATTEMPTS"},
+		{path: []string{"kafka", "fetchBackoff", "initial"}, want: "MSG_STORE_KAFKA_FETCH_BACKOFF_INITIAL"},
+		{path: []string{"kafka", "test-topic"}, want: "MSG_STORE_KAFKA_TEST_TOPIC"},
+		{path: []string{"mongo", "write_retry", "budget", "min_per_second"}, want: "MSG_STORE_MONGO_WRITE_RETRY_BUDGET_MIN_PER_SECOND"},
+		{path: []string{"tracing", "otlp_endpoint"}, want: "MSG_STORE_TRACING_OTLP_ENDPOINT"},
+		{path: []string{"grpc", "keepaliveTime"}, want: "MSG_STORE_GRPC_KEEPALIVE_TIME"},
+		{path: []string{"http", "URLPath"}, want: "MSG_STORE_HTTP_URLPATH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.path, "."), func(t *testing.T) {
+			if got := envName(tt.path); got != tt.want {
+				t.Fatalf("envName(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupEnv(t *testing.T) {
+	const name = "MSG_STORE_TEST_SECRET"
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("s3cr3t\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "value", env: map[string]string{name: "plain"}, want: "plain", wantOK: true},
+		{name: "empty value", env: map[string]string{name: ""}, want: "", wantOK: true},
+		{name: "file trims line ending", env: map[string]string{name + EnvFileSuffix: secret}, want: "s3cr3t", wantOK: true},
+		{name: "missing file", env: map[string]string{name + EnvFileSuffix: filepath.Join(dir, "nope")}, wantErr: true},
+		{name: "value and file", env: map[string]string{name: "plain", name + EnvFileSuffix: secret}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			got, ok, err := lookupEnv(name)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReadConfig) {
+					t.Fatalf("err = %v, want ErrReadConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("lookupEnv = %q, %t; want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	dir := t.TempDir()
+	password := filepath.Join(dir, "mongo")
+	if err := os.WriteFile(password, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MSG_STORE_MONGO_PASSWORD_FILE", password)
+	t.Setenv("MSG_STORE_KAFKA_DLQ_MAX_ATTEMPTS", "7")
+	t.Setenv("MSG_STORE_LOG_REDACT", "a*, b ,")
+	// Устаревшее имя применяется, если нет переменной с префиксом.
+	t.Setenv("HTTP_ADDR", ":8081")
+	// При обеих переменных побеждает переменная с префиксом.
+	t.Setenv("GRPC_ADDR", ":9091")
+	t.Setenv("MSG_STORE_GRPC_ADDR", ":9092")
+	t.Setenv("WS_PING_INTERVAL", "15s")
+
+	cfg := &Config{HTTPConfig: &HTTPConfig{Addr: ":8080"}}
+	if err := applyEnv(cfg); err != nil {
+		t.Fatalf("applyEnv: %v", err)
+	}
+
+	if got := cfg.MongoConfig.Password.Reveal(); got != "from-file" {
+		t.Errorf("mongo.password = %q, want from-file", got)
+	}
+	if got := cfg.KafkaConfig.DLQ.MaxAttempts; got != 7 {
+		t.Errorf("kafka.dlq.max-attempts = %d, want 7", got)
+	}
+	if got := strings.Join(cfg.LogConfig.Redact, "|"); got != "a*|b" {
+		t.Errorf("log.redact = %q, want a*|b", got)
+	}
+	if got := cfg.HTTPConfig.Addr; got != ":8081" {
+		t.Errorf("http.addr = %q, want :8081 from HTTP_ADDR", got)
+	}
+	if got := cfg.HTTPConfig.WebSocket.PingInterval; got != 15*time.Second {
+		t.Errorf("http.websocket.ping_interval = %s, want 15s from WS_PING_INTERVAL", got)
+	}
+	if got := cfg.GRPCConfig.Addr; got != ":9092" {
+		t.Errorf("grpc.addr = %q, want :9092 from MSG_STORE_GRPC_ADDR", got)
+	}
+
+	want := []string{
+		"HTTP_ADDR is deprecated, use MSG_STORE_HTTP_ADDR",
+		"WS_PING_INTERVAL is deprecated, use MSG_STORE_HTTP_WEBSOCKET_PING_INTERVAL",
+		"GRPC_ADDR is ignored because MSG_STORE_GRPC_ADDR is set",
+	}
+	if got := cfg.Warnings(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings = %q, want %q", got, want)
+	}
+}
+
+func TestApplyEnvInvalidValue(t *testing.T) {
+	t.Setenv("APP_GRPC_ENABLED", "maybe")
+
+	err := applyEnv(&Config{})
+	if !errors.Is(err, ErrReadConfig) || !strings.Contains(err.Error(), "APP_GRPC_ENABLED") {
+		t.Fatalf("err = %v, want ErrReadConfig naming APP_GRPC_ENABLED", err)
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+import "errors"
+
+var (
+	// ErrConfigNotFound означает, что файл конфигурации не найден.
+	ErrConfigNotFound = errors.New("config: file not found")
+	// ErrConfigEmpty означает, что файл конфигурации пуст.
+	ErrConfigEmpty = errors.New("config: file is empty")
+	// ErrReadConfig сообщает о сбое чтения или разбора конфигурации.
+	ErrReadConfig = errors.New("config: read failed")
+	// ErrInvalidConfig означает, что конфигурация не прошла проверку (см. ValidationError).
+	ErrInvalidConfig = errors.New("config: invalid configuration")
+)
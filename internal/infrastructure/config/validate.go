@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/logger"
+)
+
+// FieldError описывает проблему одного параметра конфигурации.
+// Path — путь к параметру в YAML-файле, например "kafka.dlq.topic".
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string { return e.Path + ": " + e.Message }
+
+// ValidationError перечисляет все проблемы конфигурации, найденные за один проход.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d):", ErrInvalidConfig.Error(), len(e.Fields))
+	for _, f := range e.Fields {
+		b.WriteString("\n  - ")
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrInvalidConfig).
+func (e *ValidationError) Unwrap() error { return ErrInvalidConfig }
+
+// Validate проверяет обязательные параметры, длительности, диапазоны и
+// согласованность разделов между собой. Возвращает *ValidationError со всеми
+// найденными проблемами или nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	httpEnabled, grpcEnabled := false, false
+	if v.section("app", c.AppConfig != nil) {
+		httpEnabled, grpcEnabled = c.IsHTTPEnabled, c.IsGrpcEnabled
+		v.positive("app.shutdown_timeout", c.ShutdownTimeout)
+		v.nonNegative("app.config_watch_interval", c.ConfigWatchInterval)
+	}
+	if v.section("retry", c.RetryConfig != nil) {
+		v.retry("retry", c.RetryConfig)
+	}
+	if v.section("mongo", c.MongoConfig != nil) {
+		v.mongo(c.MongoConfig)
+	}
+	if v.section("kafka", c.KafkaConfig != nil) {
+		v.kafka(c.KafkaConfig)
+	}
+	// Разделы серверов обязательны только для включенных подсистем.
+	if httpEnabled && v.section("http", c.HTTPConfig != nil) {
+		v.http(c.HTTPConfig)
+	}
+	if grpcEnabled && v.section("grpc", c.GRPCConfig != nil) {
+		v.grpc(c.GRPCConfig)
+	}
+	if c.TracingConfig != nil {
+		v.tracing(c.TracingConfig)
+	}
+	if _, err := c.LogConfig.SlogLevel(); err != nil {
+		v.add("log.level", "must be one of debug, info, warn, error, got %q", c.LogConfig.Level)
+	}
+	if c.LogConfig != nil && c.LogConfig.Format != "" && !slices.Contains(logFormats, c.LogConfig.Format) {
+		v.add("log.format", "must be one of %s, got %q", strings.Join(logFormats, ", "), c.LogConfig.Format)
+	}
+	if c.LogConfig != nil {
+		for i, pattern := range c.LogConfig.Redact {
+			if err := logger.ValidatePattern(pattern); err != nil {
+				v.add(fmt.Sprintf("log.redact[%d]", i), "invalid pattern %q", pattern)
+			}
+		}
+		v.nonNegative("log.sampling.interval", c.LogConfig.Sampling.Interval)
+		v.nonNegativeInt("log.sampling.burst", c.LogConfig.Sampling.Burst)
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.problems}
+}
+
+func (v *validator) retry(path string, cfg *RetryConfig) {
+	// Нулевые значения заменяются безопасными значениями по умолчанию (retry.DefaultPolicy).
+	v.nonNegativeInt(path+".attempts", cfg.Attempts)
+	v.nonNegative(path+".initial", cfg.Initial)
+	v.nonNegative(path+".max", cfg.Max)
+	if cfg.Initial > 0 && cfg.Max > 0 && cfg.Initial > cfg.Max {
+		v.add(path+".initial", "must not exceed %s.max (%s > %s)", path, cfg.Initial, cfg.Max)
+	}
+	if cfg.Factor != 0 && cfg.Factor < 1 {
+		v.add(path+".factor", "must be at least 1, got %g", cfg.Factor)
+	}
+	if cfg.Budget.Ratio < 0 || cfg.Budget.Ratio > 1 {
+		v.add(path+".budget.ratio", "must be between 0 and 1, got %g", cfg.Budget.Ratio)
+	}
+	if cfg.Budget.MinPerSecond < 0 {
+		v.add(path+".budget.min_per_second", "must not be negative, got %g", cfg.Budget.MinPerSecond)
+	}
+	if cfg.Budget.Burst < 0 {
+		v.add(path+".budget.burst", "must not be negative, got %g", cfg.Budget.Burst)
+	}
+	v.nonNegative(path+".hedge.delay", cfg.Hedge.Delay)
+	v.nonNegativeInt(path+".hedge.attempts", cfg.Hedge.Attempts)
+}
+
+func (v *validator) breaker(path string, cfg *BreakerConfig) {
+	v.nonNegative(path+".window", cfg.Window)
+	v.nonNegative(path+".cooldown", cfg.Cooldown)
+	v.nonNegativeInt(path+".min_requests", cfg.MinRequests)
+	v.nonNegativeInt(path+".half_open_requests", cfg.HalfOpenRequests)
+	if cfg.FailureRatio < 0 || cfg.FailureRatio > 1 {
+		v.add(path+".failure_ratio", "must be between 0 and 1, got %g", cfg.FailureRatio)
+	}
+}
+
+func (v *validator) mongo(cfg *MongoConfig) {
+	v.hostPort("mongo.addr", cfg.Addr)
+	v.required("mongo.db_name", cfg.DB)
+	v.required("mongo.messages_collection", cfg.Collection)
+	if (cfg.Username == "") != (cfg.Password == "") {
+		v.add("mongo.password", "username and password must be set together")
+	}
+	v.nonNegative("mongo.connect_timeout", cfg.ConnectTimeout)
+	v.retry("mongo.write_retry", &cfg.WriteRetry)
+	v.retry("mongo.read_retry", &cfg.ReadRetry)
+	v.breaker("mongo.breaker", &cfg.Breaker)
+	// Ноль снимает ограничение пула в драйвере, что почти никогда не нужно.
+	if cfg.MaxPoolSize == 0 || cfg.MaxPoolSize > maxMongoPoolSize {
+		v.add("mongo.max_pool_size", "must be between 1 and %d, got %d", maxMongoPoolSize, cfg.MaxPoolSize)
+	}
+}
+
+func (v *validator) kafka(cfg *KafkaConfig) {
+	v.hostPort("kafka.address", cfg.Address)
+	v.required("kafka.test-topic", cfg.TestTopic)
+	v.required("kafka.group-id", cfg.GroupID)
+	if !slices.Contains(kafkaNetworks, cfg.Network) {
+		v.add("kafka.network", "must be one of %s, got %q", strings.Join(kafkaNetworks, ", "), cfg.Network)
+	}
+
+	v.retry("kafka.fetchBackoff", &cfg.FetchBackoff)
+	v.retry("kafka.commitBackoff", &cfg.CommitBackoff)
+
+	if cfg.DLQ.Enabled {
+		v.required("kafka.dlq.topic", cfg.DLQ.Topic)
+		if cfg.DLQ.Topic != "" && cfg.DLQ.Topic == cfg.TestTopic {
+			v.add("kafka.dlq.topic", "must differ from kafka.test-topic")
+		}
+	}
+	v.nonNegativeInt("kafka.dlq.max-attempts", cfg.DLQ.MaxAttempts)
+	v.nonNegativeInt("kafka.partition-workers", cfg.PartitionWorkers)
+	v.nonNegativeInt("kafka.batch.size", cfg.Batch.Size)
+	v.nonNegative("kafka.batch.timeout", cfg.Batch.Timeout)
+	if cfg.MaxLag < 0 {
+		v.add("kafka.max-lag", "must not be negative, got %d", cfg.MaxLag)
+	}
+}
+
+func (v *validator) http(cfg *HTTPConfig) {
+	v.hostPort("http.addr", cfg.Addr)
+	v.nonNegative("http.read_header_timeout", cfg.ReadHeaderTimeout)
+	v.nonNegative("http.read_timeout", cfg.ReadTimeout)
+	v.nonNegative("http.write_timeout", cfg.WriteTimeout)
+	v.nonNegative("http.idle_timeout", cfg.IdleTimeout)
+
+	ws := &cfg.WebSocket
+	v.nonNegativeInt("http.websocket.max_in_flight", ws.MaxInFlight)
+	if ws.MaxMessageSize < 0 {
+		v.add("http.websocket.max_message_size", "must not be negative, got %d", ws.MaxMessageSize)
+	}
+	v.nonNegative("http.websocket.process_timeout", ws.ProcessTimeout)
+	// Дедлайны записи и чтения отсчитываются от этих значений: ноль рвет соединение.
+	v.positive("http.websocket.write_timeout", ws.WriteTimeout)
+	v.positive("http.websocket.ping_interval", ws.PingInterval)
+}
+
+func (v *validator) grpc(cfg *GRPCConfig) {
+	v.hostPort("grpc.addr", cfg.Addr)
+	v.nonNegative("grpc.connection_timeout", cfg.ConnectionTimeout)
+	v.nonNegative("grpc.request_timeout", cfg.RequestTimeout)
+	v.nonNegative("grpc.max_connection_idle", cfg.MaxConnectionIdle)
+	v.nonNegative("grpc.keepalive_time", cfg.KeepaliveTime)
+	v.nonNegative("grpc.keepalive_timeout", cfg.KeepaliveTimeout)
+}
+
+func (v *validator) tracing(cfg *TracingConfig) {
+	if cfg.Exporter != "" && !slices.Contains(tracingExporters, cfg.Exporter) {
+		v.add("tracing.exporter", "must be one of %s, got %q", strings.Join(tracingExporters, ", "), cfg.Exporter)
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		v.add("tracing.sample_ratio", "must be between 0 and 1, got %g", cfg.SampleRatio)
+	}
+}
+
+const maxMongoPoolSize = 10000
+
+var (
+	kafkaNetworks    = []string{"tcp", "tcp4", "tcp6"}
+	tracingExporters = []string{"none", "stdout", "otlp"}
+	logFormats       = []string{"pretty", "json", "logfmt"}
+)
+
+// validator накапливает проблемы конфигурации.
+type validator struct {
+	problems []FieldError
+}
+
+func (v *validator) add(path, format string, args ...any) {
+	v.problems = append(v.problems, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// section отмечает отсутствующий раздел и сообщает, можно ли проверять его поля.
+func (v *validator) section(path string, present bool) bool {
+	if !present {
+		v.add(path, "section is required")
+	}
+	return present
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *validator) hostPort(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+		return
+	}
+	if _, port, err := net.SplitHostPort(value); err != nil || port == "" {
+		v.add(path, "must be in host:port form, got %q", value)
+	}
+}
+
+func (v *validator) positive(path string, d time.Duration) {
+	if d <= 0 {
+		v.add(path, "must be a positive duration, got %s", d)
+	}
+}
+
+func (v *validator) nonNegative(path string, d time.Duration) {
+	if d < 0 {
+		v.add(path, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) nonNegativeInt(path string, n int) {
+	if n < 0 {
+		v.add(path, "must not be negative, got %d", n)
+	}
+}
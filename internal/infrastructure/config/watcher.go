@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Subscriber получает конфигурацию после перезагрузки, если изменился хотя бы
+// один параметр из Paths (пути или разделы, см. Change.Matches). Apply
+// вызывается из горутины Watcher и должен применять значения атомарно
+// относительно своих читателей.
+type Subscriber struct {
+	Name  string
+	Paths []string
+	Apply func(cfg *Config)
+}
+
+// Watcher перечитывает конфигурацию по SIGHUP и при изменении файлов,
+// проверяет ее, сравнивает с текущей и уведомляет подписчиков. Изменения,
+// которые не применяет ни один подписчик, вступят в силу только после
+// перезапуска — о них Watcher предупреждает в логе.
+type Watcher struct {
+	deps *WatcherDeps
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []Subscriber
+	stamps      map[string]fileStamp
+}
+
+// WatcherDeps содержит зависимости Watcher: источники, из которых
+// конфигурация была загружена, и уже загруженную конфигурацию.
+type WatcherDeps struct {
+	Opts    LoadOptions
+	Current *Config
+	Log     *slog.Logger
+}
+
+// fileStamp — признаки, по которым определяется изменение файла.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher создает наблюдателя за конфигурацией.
+func NewWatcher(deps *WatcherDeps) *Watcher {
+	w := &Watcher{
+		deps:    deps,
+		current: deps.Current,
+	}
+	w.stamps = w.stat()
+	return w
+}
+
+// Subscribe регистрирует подписчика. Вызывать до Run.
+func (w *Watcher) Subscribe(s Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, s)
+}
+
+// Current возвращает последнюю успешно загруженную конфигурацию.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Run следит за SIGHUP и, если задан app.config_watch_interval, за файлами
+// конфигурации, пока не отменен ctx.
+func (w *Watcher) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval := w.Current().ConfigWatchInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			w.deps.Log.Info("SIGHUP received, reloading config")
+			w.Reload()
+		case <-tick:
+			if w.filesChanged() {
+				w.deps.Log.Info("config file changed, reloading config")
+				w.Reload()
+			}
+		}
+	}
+}
+
+// Reload перечитывает и проверяет конфигурацию, применяет изменения через
+// подписчиков и делает новую конфигурацию текущей. При ошибке загрузки
+// текущая конфигурация сохраняется.
+func (w *Watcher) Reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stamps = w.stat()
+
+	next, err := LoadConfig(w.deps.Opts)
+	if err != nil {
+		w.deps.Log.Error("config reload failed, keeping current config", slog.Any("error", err))
+		return
+	}
+
+	for _, warning := range next.Warnings() {
+		w.deps.Log.Warn("config warning", slog.String("detail", warning))
+	}
+
+	changes := Diff(w.current, next)
+	if len(changes) == 0 {
+		w.deps.Log.Debug("config reloaded, no changes")
+		return
+	}
+
+	applied := make([]bool, len(changes))
+	for _, s := range w.subscribers {
+		var paths []string
+		for i, c := range changes {
+			if slices.ContainsFunc(s.Paths, c.Matches) {
+				applied[i] = true
+				paths = append(paths, c.Path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		s.Apply(next)
+		w.deps.Log.Info("config changes applied",
+			slog.String("subscriber", s.Name),
+			slog.Any("paths", paths),
+		)
+	}
+
+	var restart []string
+	for i, c := range changes {
+		if !applied[i] {
+			restart = append(restart, c.Path)
+		}
+	}
+	if len(restart) > 0 {
+		w.deps.Log.Warn("config changes require restart", slog.Any("paths", restart))
+	}
+
+	w.current = next
+}
+
+// filesChanged сообщает, изменились ли файлы с момента последней загрузки.
+func (w *Watcher) filesChanged() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !maps.Equal(w.stat(), w.stamps)
+}
+
+// stat снимает признаки базового файла и файла профиля.
+func (w *Watcher) stat() map[string]fileStamp {
+	paths := []string{w.deps.Opts.Path}
+	if w.deps.Opts.Profile != "" {
+		paths = append(paths, ProfilePath(w.deps.Opts.Path, w.deps.Opts.Profile))
+	}
+
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			stamps[p] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
@@ -0,0 +1,272 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"github.com/segmentio/kafka-go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchHandler обрабатывает пачку прочитанных сообщений. При частичном отказе
+// обработчик возвращает *BatchError с индексами неудачных сообщений; любая
+// другая ошибка считается отказом всей пачки.
+type BatchHandler func(ctx context.Context, batch []Delivery) error
+
+// BatchError описывает частичный отказ пакетной обработки. Ключи Failed —
+// индексы сообщений в переданной обработчику пачке.
+type BatchError struct {
+	Failed map[int]error
+}
+
+// Error возвращает краткое описание частичного отказа.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("kafka: %d batch message(s) failed", len(e.Failed))
+}
+
+// defaultBatchTimeout используется, если окно накопления пачки не задано.
+const defaultBatchTimeout = 200 * time.Millisecond
+
+// SetBatchHandler задает обработчик пакетного режима. Если он не задан,
+// сообщения пачки передаются обработчикам AddDeliveryHandler по одному.
+func (k *Kafka) SetBatchHandler(handler BatchHandler) { k.batchHandler = handler }
+
+// consumeBatched накапливает до Batch.Size сообщений или ждет не дольше
+// Batch.Timeout, обрабатывает пачку и фиксирует наибольший обработанный
+// оффсет каждой партиции одним коммитом. Если сообщение не удалось ни
+// обработать, ни отправить в DLQ, фиксируются оффсеты до него, а чтение
+// останавливается с ошибкой ErrPartitionBlocked.
+func (k *Kafka) consumeBatched(ctx context.Context) error {
+	backoff := retry.NewBackoff(k.deps.Retry.Policy(config.RetryKafkaFetch))
+	tracker := newOffsetTracker()
+
+	for {
+		batch, ok := k.collect(ctx, backoff)
+		if len(batch) > 0 {
+			if err := k.processBatch(ctx, tracker, batch); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// collect читает пачку сообщений. Ожидание первого сообщения не ограничено,
+// остальные дочитываются, пока не истечет Batch.Timeout. Возвращает false,
+// когда консюмер остановлен; уже прочитанные сообщения при этом отдаются.
+func (k *Kafka) collect(ctx context.Context, backoff *retry.Backoff) ([]kafka.Message, bool) {
+	size := max(k.tuned().batchSize, 1)
+
+	first, ok := k.next(ctx, backoff)
+	if !ok {
+		return nil, false
+	}
+
+	batch := make([]kafka.Message, 0, size)
+	batch = append(batch, first)
+
+	timeout := k.tuned().batchTimeout
+	if timeout <= 0 {
+		timeout = defaultBatchTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for len(batch) < size {
+		msg, err := k.fetch(waitCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return batch, false
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				// Чтение будет повторено, см. next.
+				k.deps.Log.Warn("fetch failed", "err", fmt.Errorf("%w: %w", ErrFetchMessage, err))
+			}
+			return batch, true
+		}
+		batch = append(batch, msg)
+	}
+
+	return batch, true
+}
+
+// processBatch обрабатывает пачку: повторяет только неудачные сообщения с
+// временными ошибками, исчерпавшие попытки или отклоненные окончательно
+// (retry.Permanent) отправляет в DLQ и фиксирует оффсеты. Возвращает
+// ErrPartitionBlocked для первого сообщения, которое не удалось ни
+// обработать, ни отправить в DLQ.
+func (k *Kafka) processBatch(ctx context.Context, tracker *offsetTracker, batch []kafka.Message) error {
+	inflightCtx := context.WithoutCancel(ctx)
+
+	for _, msg := range batch {
+		if tracker.track(msg) {
+			k.deps.Log.Debug("partition rewound, stale offsets dropped",
+				"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+		}
+	}
+
+	maxAttempts := max(k.tuned().maxAttempts, 1)
+	b := retry.NewBackoff(k.deps.Retry.Policy(config.RetryKafkaHandle))
+
+	pending := make([]int, len(batch))
+	for i := range batch {
+		pending[i] = i
+	}
+
+	failed := make(map[int]error)
+	var attempts int
+	for attempts = 1; ; attempts++ {
+		var retryable []int
+		blocked := false
+		for i, err := range k.handleBatch(inflightCtx, batch, pending) {
+			failed[i] = err
+			switch {
+			case k.storageUnavailable(err):
+				blocked = true
+				retryable = append(retryable, i)
+			case retry.Retryable(err, nil):
+				retryable = append(retryable, i)
+			}
+		}
+		if len(retryable) == 0 || ctx.Err() != nil {
+			break
+		}
+		if blocked {
+			// Хранилище недоступно — ждем предохранитель, не расходуя попытки.
+			attempts--
+			if !k.waitStorage(ctx) {
+				break
+			}
+		} else {
+			if attempts >= maxAttempts {
+				break
+			}
+			k.deps.Log.Warn("batch retry", "attempt", attempts, "failed", len(retryable), "size", len(batch))
+			b.Sleep(ctx)
+		}
+
+		for _, i := range retryable {
+			delete(failed, i)
+		}
+		pending = retryable
+		slices.Sort(pending)
+	}
+
+	var blocked error
+	commits := make(map[topicPartition]kafka.Message)
+	for i, msg := range batch {
+		if err, ok := failed[i]; ok {
+			k.deps.Metrics.KafkaFailed(msg.Topic, msg.Partition)
+			if !k.rejectFromBatch(ctx, msg, attempts, err) {
+				if blocked == nil && ctx.Err() == nil {
+					blocked = blockedBy(msg)
+				}
+				continue
+			}
+		} else {
+			k.deps.Metrics.KafkaHandled(msg.Topic, msg.Partition)
+		}
+		if commit, ok := tracker.done(msg); ok {
+			commits[partitionOf(commit)] = commit
+		}
+	}
+	if len(commits) == 0 {
+		return blocked
+	}
+
+	msgs := make([]kafka.Message, 0, len(commits))
+	for _, commit := range commits {
+		msgs = append(msgs, commit)
+	}
+
+	if err := k.commitWithRetry(inflightCtx, msgs...); err != nil {
+		k.deps.Log.Error("commit failed", "err", err,
+			"size", len(batch), "partitions", len(commits))
+		return blocked
+	}
+	for _, commit := range msgs {
+		k.deps.Metrics.KafkaCommitted(commit.Topic, commit.Partition)
+	}
+	k.deps.Log.Debug("batch committed", "size", len(batch), "failed", len(failed),
+		"partitions", len(commits))
+	return blocked
+}
+
+// handleBatch обрабатывает сообщения пачки с индексами idx и возвращает
+// ошибки по индексам исходной пачки.
+func (k *Kafka) handleBatch(ctx context.Context, batch []kafka.Message, idx []int) map[int]error {
+	failed := make(map[int]error)
+
+	if k.batchHandler == nil {
+		for _, i := range idx {
+			if err := k.handle(ctx, batch[i]); err != nil {
+				failed[i] = err
+			}
+		}
+		return failed
+	}
+
+	deliveries := make([]Delivery, len(idx))
+	msgs := make([]kafka.Message, len(idx))
+	for j, i := range idx {
+		deliveries[j] = newDelivery(batch[i])
+		msgs[j] = batch[i]
+	}
+
+	ctx, span := tracer().Start(ctx, "kafka.handle_batch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(linksTo(msgs)...),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msgs[0].Topic),
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingBatchMessageCount(len(msgs)),
+		),
+	)
+	start := time.Now()
+	err := k.batchHandler(ctx, deliveries)
+	k.deps.Metrics.KafkaHandlerDuration(msgs[0].Topic, time.Since(start), err)
+	endSpan(span, err)
+
+	var batchErr *BatchError
+	switch {
+	case err == nil:
+	case errors.As(err, &batchErr):
+		for j, itemErr := range batchErr.Failed {
+			if j >= 0 && j < len(idx) {
+				failed[idx[j]] = itemErr
+			}
+		}
+	default:
+		for _, i := range idx {
+			failed[i] = err
+		}
+	}
+	return failed
+}
+
+// rejectFromBatch отправляет неудачное сообщение в DLQ. Возвращает true,
+// если после этого оффсет сообщения можно фиксировать.
+func (k *Kafka) rejectFromBatch(ctx context.Context, msg kafka.Message, attempts int, cause error) bool {
+	k.deps.Log.Error("handler failed", "err", cause, "topic", msg.Topic,
+		"offset", msg.Offset, "attempts", attempts)
+	if !k.dlqEnabled() || ctx.Err() != nil {
+		return false
+	}
+	if err := k.toDLQ(context.WithoutCancel(ctx), msg, attempts, cause); err != nil {
+		k.deps.Log.Error("dlq failed", "err", err, "topic", msg.Topic, "offset", msg.Offset)
+		return false
+	}
+	k.deps.Metrics.KafkaDLQ(msg.Topic, msg.Partition)
+	k.deps.Log.Warn("message moved to dlq", "topic", msg.Topic, "offset", msg.Offset,
+		"dlq_topic", k.deps.Cfg.DLQ.Topic)
+	return true
+}
@@ -0,0 +1,31 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+)
+
+// storageUnavailable сообщает, что обработчик не выполнился из-за
+// разомкнутого предохранителя хранилища. Такие неудачи не расходуют попытки:
+// консюмер дожидается предохранителя (см. waitStorage).
+func (k *Kafka) storageUnavailable(err error) bool {
+	return k.deps.StorageBreaker != nil && errors.Is(err, retry.ErrBreakerOpen)
+}
+
+// waitStorage приостанавливает консюмер, пока предохранитель хранилища не
+// пропустит вызов. Возвращает false, если консюмер остановлен во время ожидания.
+func (k *Kafka) waitStorage(ctx context.Context) bool {
+	b := k.deps.StorageBreaker
+	if b == nil || b.State() == retry.StateClosed {
+		return true
+	}
+
+	k.deps.Log.Warn("storage unavailable, consumer paused", "breaker", b.Name())
+	if err := b.Wait(ctx); err != nil {
+		return false
+	}
+	k.deps.Log.Info("consumer resumed", "breaker", b.Name(), "state", b.State().String())
+	return true
+}
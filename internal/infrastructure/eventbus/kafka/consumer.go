@@ -2,9 +2,19 @@
 package kafka
 
 import (
+	"context"
+
 	"github.com/segmentio/kafka-go"
 )
 
+// reader — часть kafka.Reader, которой пользуется консюмер.
+type reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Stats() kafka.ReaderStats
+	Close() error
+}
+
 // createReader возвращает подготовленный kafka.Reader для заданных адреса, топика и группы.
 func createReader(address, topic, groupID string) *kafka.Reader {
 	r := kafka.NewReader(kafka.ReaderConfig{
@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Delivery описывает прочитанное из топика сообщение вместе с его координатами.
+type Delivery struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Time      time.Time
+}
+
+// DeliveryHandler обрабатывает прочитанное сообщение. Возврат ошибки
+// означает, что оффсет сообщения фиксировать нельзя.
+type DeliveryHandler func(ctx context.Context, d Delivery) error
+
+func newDelivery(m kafka.Message) Delivery {
+	return Delivery{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Time:      m.Time,
+	}
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки, которыми снабжается сообщение при публикации в DLQ.
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderError             = "x-error"
+	HeaderErrorChain        = "x-error-chain"
+	HeaderAttempts          = "x-attempts"
+)
+
+// dlqEnabled сообщает, настроена ли публикация в dead-letter очередь.
+func (k *Kafka) dlqEnabled() bool { return k.dlq != nil }
+
+// toDLQ публикует исходное сообщение в DLQ-топик, сохраняя ключ, тело и
+// заголовки, и добавляет к ним координаты оригинала, цепочку ошибок и
+// число выполненных попыток.
+func (k *Kafka) toDLQ(ctx context.Context, m kafka.Message, attempts int, cause error) error {
+	chain, err := json.Marshal(errorChain(cause))
+	if err != nil {
+		return fmt.Errorf("%w: encode error chain: %w", ErrPublishDLQ, err)
+	}
+
+	headers := make([]kafka.Header, 0, len(m.Headers)+6)
+	headers = append(headers, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(m.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderErrorChain, Value: chain},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+	)
+
+	err = k.dlq.WriteMessages(ctx, kafka.Message{
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPublishDLQ, err)
+	}
+	return nil
+}
+
+// errorChain разворачивает цепочку ошибок (включая errors.Join) в список сообщений
+// в порядке обхода в глубину. Прозрачные обертки вроде retry.Permanent, чей
+// текст совпадает с обернутой ошибкой, не дублируются.
+func errorChain(err error) []string {
+	var chain []string
+	var walk func(error)
+	walk = func(e error) {
+		if e == nil {
+			return
+		}
+		if msg := e.Error(); len(chain) == 0 || chain[len(chain)-1] != msg {
+			chain = append(chain, msg)
+		}
+		switch u := e.(type) { //nolint:errorlint // нужен доступ к методам Unwrap
+		case interface{ Unwrap() []error }:
+			for _, child := range u.Unwrap() {
+				walk(child)
+			}
+		default:
+			walk(errors.Unwrap(e))
+		}
+	}
+	walk(err)
+	return chain
+}
@@ -11,4 +11,12 @@ var (
 	ErrFetchMessage = errors.New("kafka: fetch message failed")
 	// ErrCommitMessage означает ошибку подтверждения оффсета.
 	ErrCommitMessage = errors.New("kafka: commit message failed")
+	// ErrPublishDLQ сообщает о неудачной публикации сообщения в dead-letter очередь.
+	ErrPublishDLQ = errors.New("kafka: publish to dlq failed")
+	// ErrConsumerLag означает, что отставание консюмера превысило допустимый порог.
+	ErrConsumerLag = errors.New("kafka: consumer lag exceeded")
+	// ErrPartitionBlocked означает, что сообщение не удалось ни обработать, ни
+	// отправить в DLQ: оффсеты его партиции не могут продвинуться, и чтение
+	// остановлено. После перезапуска сообщение будет доставлено повторно.
+	ErrPartitionBlocked = errors.New("kafka: partition blocked by unprocessed message")
 )
@@ -5,10 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/metrics"
 	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"github.com/devoraq/AVQ_message_store/pkg/retry/kafkaretry"
 	"github.com/segmentio/kafka-go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Kafka управляет жизненным циклом соединений с Kafka:
@@ -16,20 +22,30 @@ import (
 // и предоставляет базовые операции отправки/чтения сообщений.
 type Kafka struct {
 	name     string
-	consumer *kafka.Reader
+	consumer reader
 	producer *kafka.Writer
+	dlq      *kafka.Writer
 	deps     *KafkaDeps
 
-	handlers []func(ctx context.Context, payload []byte) error
+	handlers     []DeliveryHandler
+	batchHandler BatchHandler
+
+	tunables atomic.Pointer[tunables]
 }
 
 // KafkaDeps содержит зависимости рантайма для Kafka-адаптера:
-// логгер и конфигурацию подключения к брокеру.
+// логгер, конфигурацию подключения к брокеру, (необязательно) метрики и
+// реестр политик повторов. Без реестра политики строятся из Cfg и не
+// обновляются на лету. StorageBreaker — необязательный предохранитель
+// хранилища: пока он разомкнут, консюмер не читает сообщения.
 //
 //nolint:revive // осознанно оставляем имя KafkaDeps
 type KafkaDeps struct {
-	Cfg *config.Config
-	Log *slog.Logger
+	Cfg            *config.Config
+	Log            *slog.Logger
+	Metrics        *metrics.Metrics
+	Retry          *retry.Registry
+	StorageBreaker *retry.Breaker
 }
 
 // NewKafka валидирует переданные зависимости и возвращает экземпляр адаптера.
@@ -41,11 +57,16 @@ func NewKafka(deps *KafkaDeps) *Kafka {
 	if deps.Log == nil {
 		panic("Logger cannot be nil")
 	}
+	if deps.Retry == nil {
+		deps.Retry = retry.NewRegistry(deps.Cfg.RetryPolicies())
+	}
 
-	return &Kafka{
+	k := &Kafka{
 		name: "kafka",
 		deps: deps,
 	}
+	k.ApplyConfig(deps.Cfg)
+	return k
 }
 
 // Name возвращает символьный идентификатор компонента.
@@ -73,6 +94,9 @@ func (k *Kafka) Start(ctx context.Context) error {
 
 	k.consumer = createReader(k.deps.Cfg.Address, k.deps.Cfg.TestTopic, k.deps.Cfg.GroupID)
 	k.producer = createWriter(k.deps.Cfg.Address, k.deps.Cfg.TestTopic)
+	if k.deps.Cfg.DLQ.Enabled {
+		k.dlq = createWriter(k.deps.Cfg.Address, k.deps.Cfg.DLQ.Topic)
+	}
 
 	return nil
 }
@@ -105,6 +129,18 @@ func (k *Kafka) Stop(_ context.Context) error {
 		}
 	}
 
+	if k.dlq != nil {
+		if err := k.dlq.Close(); err != nil {
+			k.deps.Log.Error(
+				"Failed to close Kafka DLQ producer connection",
+				slog.String("address", k.deps.Cfg.Address),
+				slog.String("topic", k.deps.Cfg.DLQ.Topic),
+				slog.String("error", err.Error()),
+			)
+			return fmt.Errorf("close kafka dlq producer: %w", err)
+		}
+	}
+
 	k.deps.Log.Debug(
 		"Kafka connections closed",
 		slog.String("address", k.deps.Cfg.Address),
@@ -114,6 +150,22 @@ func (k *Kafka) Stop(_ context.Context) error {
 	return nil
 }
 
+// HealthCheck проверяет доступность брокера и, если задан MaxLag,
+// что отставание консюмера от конца партиции не превышает порог.
+func (k *Kafka) HealthCheck(ctx context.Context) error {
+	if err := ensureKafkaConnection(ctx, k.deps.Cfg.Network, k.deps.Cfg.Address); err != nil {
+		return fmt.Errorf("%w: %w", ErrEnsureConnection, err)
+	}
+
+	if k.consumer == nil || k.deps.Cfg.MaxLag <= 0 {
+		return nil
+	}
+	if lag := k.consumer.Stats().Lag; lag > k.deps.Cfg.MaxLag {
+		return fmt.Errorf("%w: lag %d exceeds %d", ErrConsumerLag, lag, k.deps.Cfg.MaxLag)
+	}
+	return nil
+}
+
 // ensureKafkaConnection выполняет проверку доступности брокера:
 // открывает и закрывает TCP-соединение к адресу Kafka.
 // Не экспортируется намеренно.
@@ -130,13 +182,25 @@ func ensureKafkaConnection(ctx context.Context, network, address string) error {
 
 // WriteMessage публикует одно сообщение в настроенный Kafka-топик.
 // Возвращает ошибку с обёрткой при сбое записи.
-func (k *Kafka) WriteMessage(ctx context.Context, msg []byte) error {
-	err := k.producer.WriteMessages(ctx,
-		kafka.Message{
-			Key:   nil,
-			Value: msg,
-		},
+// Контекст трассировки из ctx передаётся консюмерам в заголовках сообщения.
+func (k *Kafka) WriteMessage(ctx context.Context, msg []byte) (err error) {
+	ctx, span := tracer().Start(ctx, "kafka.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(k.deps.Cfg.TestTopic),
+		),
 	)
+	defer func() { endSpan(span, err) }()
+
+	message := kafka.Message{
+		Key:   nil,
+		Value: msg,
+	}
+	injectContext(ctx, &message)
+
+	err = k.producer.WriteMessages(ctx, message)
 	if err != nil {
 		k.deps.Log.Error("kafka write message failed", "err", fmt.Errorf("%w: %w", ErrWriteMessage, err))
 		return fmt.Errorf("%w: %w", ErrWriteMessage, err)
@@ -144,8 +208,9 @@ func (k *Kafka) WriteMessage(ctx context.Context, msg []byte) error {
 	return nil
 }
 
-// AddDeliveryHandler регистрирует обработчик, который будет вызван после коммита сообщения.
-func (k *Kafka) AddDeliveryHandler(handler func(ctx context.Context, payload []byte) error) {
+// AddDeliveryHandler регистрирует обработчик, который будет вызван для каждого
+// прочитанного сообщения до коммита его оффсета.
+func (k *Kafka) AddDeliveryHandler(handler DeliveryHandler) {
 	if handler == nil {
 		return
 	}
@@ -155,52 +220,124 @@ func (k *Kafka) AddDeliveryHandler(handler func(ctx context.Context, payload []b
 // StartConsuming запускает непрерывное чтение сообщений из топика
 // с коммитом оффсетов. Останавливается при отмене контекста.
 // При временных ошибках чтения делает паузы и продолжает работу.
-func (k *Kafka) StartConsuming(ctx context.Context) {
+// Отмена контекста не прерывает уже прочитанное сообщение: его обработка
+// и коммит завершаются до выхода из цикла.
+//
+// В пакетном режиме (Batch.Enabled) сообщения обрабатываются пачками
+// (см. consumeBatched). Иначе, если PartitionWorkers больше единицы, сообщения
+// распределяются по воркерам партиций (см. consumePartitioned), а в остальных
+// случаях обрабатываются последовательно.
+//
+// Возвращает ErrPartitionBlocked, если чтение остановлено из-за сообщения,
+// которое не удалось ни обработать, ни отправить в DLQ; после остановки по
+// ctx возвращает nil.
+func (k *Kafka) StartConsuming(ctx context.Context) error {
 	defer func() {
 		if err := k.consumer.Close(); err != nil {
 			k.deps.Log.Warn("consumer close failed", "err", err)
 		}
 	}() // безопасное закрытие
 
-	backoff := retry.NewBackoff(k.deps.Cfg)
+	if k.deps.Cfg.Batch.Enabled {
+		return k.consumeBatched(ctx)
+	}
+	if k.deps.Cfg.PartitionWorkers > 1 {
+		return k.consumePartitioned(ctx, k.deps.Cfg.PartitionWorkers)
+	}
+	return k.consumeSequential(ctx)
+}
+
+// consumeSequential обрабатывает сообщения всего ридера строго по одному.
+// Коммит следующего сообщения зафиксировал бы и все предыдущие, поэтому
+// на сообщении, которое не удалось ни обработать, ни отправить в DLQ, чтение
+// останавливается с ошибкой ErrPartitionBlocked.
+func (k *Kafka) consumeSequential(ctx context.Context) error {
+	backoff := retry.NewBackoff(k.deps.Retry.Policy(config.RetryKafkaFetch))
 
 	for {
-		if ctx.Err() != nil {
+		msg, ok := k.next(ctx, backoff)
+		if !ok {
+			return nil
+		}
+
+		if !k.process(ctx, msg) {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return blockedBy(msg)
+		}
+
+		// Прочитанное сообщение доводим до конца даже при остановке консюмера.
+		if err := k.commitWithRetry(context.WithoutCancel(ctx), msg); err != nil {
+			k.deps.Log.Error("commit failed", "err", err,
+				"topic", msg.Topic, "offset", msg.Offset)
+			// Не удалось зафиксировать — сообщение придет снова (at-least-once).
+			continue
+		}
+		k.deps.Metrics.KafkaCommitted(msg.Topic, msg.Partition)
+		k.deps.Log.Debug("message committed", "topic", msg.Topic, "offset", msg.Offset)
+	}
+}
+
+// next читает очередное сообщение, выдерживая паузы при ошибках чтения и
+// пока разомкнут предохранитель хранилища. Возвращает false, когда консюмер
+// остановлен.
+func (k *Kafka) next(ctx context.Context, backoff *retry.Backoff) (kafka.Message, bool) {
+	for {
+		if ctx.Err() != nil || !k.waitStorage(ctx) {
 			k.deps.Log.Debug("Kafka consumer stopped", "err", ctx.Err())
-			return
+			return kafka.Message{}, false
 		}
 
 		msg, err := k.fetch(ctx)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				k.deps.Log.Debug("Kafka consumer context canceled")
-				return
+				return kafka.Message{}, false
 			}
-			k.deps.Log.Error("fetch failed", "err", fmt.Errorf("%w: %w", ErrFetchMessage, err))
+			// Чтение повторяется до остановки консюмера, поэтому сбой — не
+			// ошибка, а предупреждение: при недоступной Kafka такие записи
+			// ограничивает logger.SamplingHandler.
+			k.deps.Log.Warn("fetch failed", "err", fmt.Errorf("%w: %w", ErrFetchMessage, err))
 			backoff.Sleep(ctx)
 			continue
 		}
 		backoff.Reset()
+		return msg, true
+	}
+}
 
-		if err := k.handle(ctx, msg); err != nil {
-			// Если обработка упала — НЕ коммитим, чтобы переиграть позже.
-			k.deps.Log.Error("handler failed", "err", err, "topic", msg.Topic, "offset", msg.Offset)
-			//! либо ретраим локально с ограничением, либо отдаем в DLQ.
-			// if dlqErr := k.toDLQ(ctx, msg, err); dlqErr != nil {
-			// 	k.deps.Log.Error("dlq failed", "err", dlqErr)
-			//! 	// Без DLQ оставляем без коммита → повторная доставка.
-			// }
-			continue
-		}
+// process обрабатывает сообщение с локальными повторами и, при необходимости,
+// отправляет его в DLQ. Возвращает true, если оффсет сообщения можно фиксировать.
+func (k *Kafka) process(ctx context.Context, msg kafka.Message) bool {
+	attempts, err := k.handleWithRetry(ctx, msg)
+	if err == nil {
+		k.deps.Metrics.KafkaHandled(msg.Topic, msg.Partition)
+		return true
+	}
 
-		if err := k.commitWithRetry(ctx, msg); err != nil {
-			k.deps.Log.Error("commit failed", "err", fmt.Errorf("%w: %w", ErrCommitMessage, err),
-				"topic", msg.Topic, "offset", msg.Offset)
-			// Не удалось зафиксировать — сообщение придет снова (at-least-once).
-			continue
-		}
-		k.deps.Log.Debug("message committed", "topic", msg.Topic, "offset", msg.Offset)
+	k.deps.Metrics.KafkaFailed(msg.Topic, msg.Partition)
+	k.deps.Log.Error("handler failed", "err", err, "topic", msg.Topic,
+		"offset", msg.Offset, "attempts", attempts)
+	// Без DLQ или при остановке консюмера не коммитим: вызывающий прекращает
+	// чтение, и после перезапуска сообщение будет доставлено повторно.
+	if !k.dlqEnabled() || ctx.Err() != nil {
+		return false
 	}
+	if dlqErr := k.toDLQ(context.WithoutCancel(ctx), msg, attempts, err); dlqErr != nil {
+		k.deps.Log.Error("dlq failed", "err", dlqErr, "topic", msg.Topic, "offset", msg.Offset)
+		return false
+	}
+	k.deps.Metrics.KafkaDLQ(msg.Topic, msg.Partition)
+	k.deps.Log.Warn("message moved to dlq", "topic", msg.Topic, "offset", msg.Offset,
+		"dlq_topic", k.deps.Cfg.DLQ.Topic)
+	return true
+}
+
+// blockedBy возвращает ErrPartitionBlocked с координатами сообщения m.
+func blockedBy(m kafka.Message) error {
+	return fmt.Errorf("%w: topic %s, partition %d, offset %d",
+		ErrPartitionBlocked, m.Topic, m.Partition, m.Offset)
 }
 
 func (k *Kafka) fetch(ctx context.Context) (kafka.Message, error) {
@@ -208,44 +345,119 @@ func (k *Kafka) fetch(ctx context.Context) (kafka.Message, error) {
 	if err != nil {
 		return kafka.Message{}, fmt.Errorf("error fetch: %w", err)
 	}
+	k.deps.Metrics.KafkaFetched(m.Topic, m.Partition)
+
+	// Отмечаем получение в трассе продюсера; обработка и коммит получат
+	// тот же родительский контекст из заголовков (см. handle и commitWithRetry).
+	_, span := tracer().Start(messageContext(ctx, m), "kafka.receive",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append(messageAttributes(m), semconv.MessagingOperationTypeReceive)...),
+	)
+	span.End()
+
 	k.deps.Log.Debug("message received", "topic", m.Topic, "offset", m.Offset, "size", len(m.Value))
 	return m, nil
 }
 
-func (k *Kafka) handle(ctx context.Context, m kafka.Message) error {
-	//! Важно: сохраняем порядок внутри партиции. Если нужно параллелить —
-	//! делаем воркер-пул на уровне партиций, но не нарушаем порядок для одного partition.
-	var firstErr error
+// handleWithRetry вызывает обработчики до успеха, но не более DLQ.MaxAttempts раз.
+// Ошибка, помеченная retry.Permanent, сразу завершает попытки, а отказ из-за
+// разомкнутого предохранителя хранилища попыткой не считается. Постоянные
+// ошибки записи в хранилище приходят помеченными Permanent и здесь не
+// повторяются; временные повторяются после паузы kafka.handle.
+// Сами вызовы выполняются в контексте без отмены, а паузы между попытками
+// прерываются остановкой консюмера. Возвращает число выполненных попыток.
+func (k *Kafka) handleWithRetry(ctx context.Context, m kafka.Message) (int, error) {
+	inflightCtx := context.WithoutCancel(ctx)
+
+	maxAttempts := max(k.tuned().maxAttempts, 1)
+	b := retry.NewBackoff(k.deps.Retry.Policy(config.RetryKafkaHandle))
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = k.handle(inflightCtx, m); err == nil {
+			return attempt, nil
+		}
+		if k.storageUnavailable(err) {
+			attempt--
+			if !k.waitStorage(ctx) {
+				return attempt, err
+			}
+			continue
+		}
+		if attempt >= maxAttempts || !retry.Retryable(err, nil) || ctx.Err() != nil {
+			return attempt, err
+		}
+		k.deps.Log.Warn("handler retry", "attempt", attempt, "err", err,
+			"topic", m.Topic, "offset", m.Offset)
+		b.Sleep(ctx)
+	}
+}
+
+func (k *Kafka) handle(ctx context.Context, m kafka.Message) (firstErr error) {
+	//! Важно: сохраняем порядок внутри партиции. Параллелизм допустим только
+	//! между партициями (см. consumePartitioned).
+	d := newDelivery(m)
+
+	ctx, span := tracer().Start(messageContext(ctx, m), "kafka.handle",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append(messageAttributes(m), semconv.MessagingOperationName("process"))...),
+	)
+	start := time.Now()
+	defer func() {
+		k.deps.Metrics.KafkaHandlerDuration(m.Topic, time.Since(start), firstErr)
+		endSpan(span, firstErr)
+	}()
+
 	for _, h := range k.handlers {
 		if h == nil {
 			continue
 		}
-		if err := h(ctx, m.Value); err != nil && firstErr == nil {
+		if err := h(ctx, d); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
 	return firstErr
 }
 
-func (k *Kafka) commitWithRetry(ctx context.Context, m kafka.Message) error {
-	b := retry.NewBackoff(k.deps.Cfg)
-	for attempts := 0; attempts < k.deps.Cfg.CommitBackoff.Attempts; attempts++ {
-		if err := k.consumer.CommitMessages(ctx, m); err != nil {
-			if ctx.Err() != nil {
-				return ctx.Err()
+// commitWithRetry фиксирует оффсеты сообщений, повторяя попытки по политике
+// config.RetryKafkaCommit (kafka.commitBackoff).
+// Коммит одного сообщения попадает в трассу его продюсера, коммит нескольких
+// связывается с их трассами ссылками.
+func (k *Kafka) commitWithRetry(ctx context.Context, msgs ...kafka.Message) (err error) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient)}
+	switch len(msgs) {
+	case 0:
+	case 1:
+		ctx = messageContext(ctx, msgs[0])
+		opts = append(opts, trace.WithAttributes(messageAttributes(msgs[0])...))
+	default:
+		opts = append(opts,
+			trace.WithLinks(linksTo(msgs)...),
+			trace.WithAttributes(
+				semconv.MessagingSystemKafka,
+				semconv.MessagingBatchMessageCount(len(msgs)),
+			),
+		)
+	}
+	ctx, span := tracer().Start(ctx, "kafka.commit", opts...)
+	defer func() { endSpan(span, err) }()
+
+	err = retry.Do(ctx, k.deps.Retry.Policy(config.RetryKafkaCommit), func(ctx context.Context) error {
+		return k.consumer.CommitMessages(ctx, msgs...)
+	},
+		retry.WithName(config.RetryKafkaCommit),
+		retry.WithClassifier(kafkaretry.Classify),
+		retry.WithBudget(k.deps.Retry.Budget(config.RetryKafkaCommit)),
+		retry.OnRetry(func(attempt int, delay time.Duration, err error) {
+			k.deps.Log.Warn("commit retry", "attempt", attempt, "delay", delay, "err", err)
+			k.deps.Metrics.RetryAttempt(config.RetryKafkaCommit)
+			if len(msgs) > 0 {
+				k.deps.Metrics.KafkaCommitRetry(msgs[0].Topic)
 			}
-			k.deps.Log.Warn("commit retry", "attempt", attempts+1, "err", err)
-			b.Sleep(ctx)
-			continue
-		}
-		return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCommitMessage, err)
 	}
-	return fmt.Errorf("commit retries exceeded: %w", ErrCommitMessage)
+	return nil
 }
-
-// func (k *Kafka) toDLQ(ctx context.Context, m kafka.Message, cause error) error {
-// 	if k.dlq == nil {
-// 		return nil
-// 	}
-// 	return k.dlq.Publish(ctx, m, cause)
-// }
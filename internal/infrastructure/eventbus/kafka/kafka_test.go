@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/logger"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader отдает заранее заданные сообщения и запоминает коммиты.
+type fakeReader struct {
+	mu        sync.Mutex
+	msgs      []kafka.Message
+	committed []int64
+	// fetchErrs — сколько первых чтений завершатся ошибкой.
+	fetchErrs int
+	// idle вызывается, когда читать больше нечего.
+	idle func()
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if r.fetchErrs > 0 {
+		r.fetchErrs--
+		r.mu.Unlock()
+		return kafka.Message{}, errBrokerDown
+	}
+	if len(r.msgs) > 0 {
+		m := r.msgs[0]
+		r.msgs = r.msgs[1:]
+		r.mu.Unlock()
+		return m, nil
+	}
+	r.mu.Unlock()
+
+	if r.idle != nil {
+		r.idle()
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range msgs {
+		r.committed = append(r.committed, m.Offset)
+	}
+	return nil
+}
+
+var errBrokerDown = errors.New("broker unreachable")
+
+func (r *fakeReader) Stats() kafka.ReaderStats { return kafka.ReaderStats{} }
+
+func (r *fakeReader) Close() error { return nil }
+
+func TestConsumeSequentialStopsOnUnprocessedMessage(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	r := &fakeReader{msgs: []kafka.Message{msgAt(0, 0), msgAt(0, 1), msgAt(0, 2)}}
+
+	k := NewKafka(&KafkaDeps{
+		Cfg: &config.Config{KafkaConfig: &config.KafkaConfig{
+			// DLQ выключена: сообщение с ошибкой некуда отложить.
+			DLQ: config.DLQConfig{MaxAttempts: 1},
+		}},
+		Log: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	k.consumer = r
+	k.AddDeliveryHandler(func(_ context.Context, d Delivery) error {
+		if d.Offset == 1 {
+			return errHandler
+		}
+		return nil
+	})
+
+	err := k.StartConsuming(context.Background())
+	if !errors.Is(err, ErrPartitionBlocked) {
+		t.Fatalf("StartConsuming = %v, want ErrPartitionBlocked", err)
+	}
+
+	// Коммит оффсета 2 зафиксировал бы и необработанный оффсет 1.
+	if len(r.committed) != 1 || r.committed[0] != 0 {
+		t.Fatalf("committed offsets = %v, want [0]", r.committed)
+	}
+	if len(r.msgs) != 1 {
+		t.Fatalf("consumer kept reading after blocked message: %d left", len(r.msgs))
+	}
+}
+
+func TestFetchFailuresAreSampled(t *testing.T) {
+	var buf bytes.Buffer
+	sampling := logger.NewSamplingHandler(slog.NewTextHandler(&buf, nil),
+		logger.SampleOptions{Interval: time.Hour, Burst: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &fakeReader{fetchErrs: 10, idle: cancel}
+
+	k := NewKafka(&KafkaDeps{
+		Cfg: &config.Config{KafkaConfig: &config.KafkaConfig{
+			FetchBackoff: config.RetryConfig{Initial: time.Microsecond, Max: time.Microsecond, Factor: 1},
+		}},
+		Log: slog.New(sampling),
+	})
+	k.consumer = r
+
+	if err := k.StartConsuming(ctx); err != nil {
+		t.Fatalf("StartConsuming = %v", err)
+	}
+	_ = sampling.Run(ctx) // ctx отменен: выводит сводки и возвращается
+
+	out := buf.String()
+	if got := strings.Count(out, ` msg="fetch failed"`); got != 3 {
+		t.Errorf("fetch failed logged %d times, want 3\n%s", got, out)
+	}
+	if !strings.Contains(out, `msg="suppressed 7 similar messages"`) {
+		t.Errorf("no summary for suppressed fetch failures\n%s", out)
+	}
+}
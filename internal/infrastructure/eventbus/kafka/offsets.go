@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker отслеживает прочитанные, но ещё не зафиксированные сообщения
+// по партициям. Оффсет разрешено коммитить только тогда, когда обработаны
+// все более ранние сообщения той же партиции. Партиция без незафиксированных
+// сообщений удаляется, поэтому состояние отозванных партиций не накапливается.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition][]trackedMessage
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+func partitionOf(m kafka.Message) topicPartition {
+	return topicPartition{topic: m.Topic, partition: m.Partition}
+}
+
+type trackedMessage struct {
+	msg  kafka.Message
+	done bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition][]trackedMessage)}
+}
+
+// track регистрирует прочитанное сообщение. Вызывается в порядке чтения.
+// Оффсет, не превышающий уже отслеживаемые, означает, что партиция читается
+// заново с последнего коммита (после перебалансировки группы): прежние
+// записи начиная с этого оффсета отбрасываются, и track возвращает true.
+func (t *offsetTracker) track(m kafka.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tp := partitionOf(m)
+	pending := t.partitions[tp]
+	keep := len(pending)
+	for keep > 0 && pending[keep-1].msg.Offset >= m.Offset {
+		keep--
+	}
+	t.partitions[tp] = append(pending[:keep], trackedMessage{msg: m})
+	return keep < len(pending)
+}
+
+// done отмечает сообщение обработанным и возвращает сообщение с наибольшим
+// оффсетом, до которого партиция обработана без пропусков. Второе значение
+// равно false, если фиксировать пока нечего. Сообщения, которые уже не
+// отслеживаются (отброшены track), игнорируются.
+func (t *offsetTracker) done(m kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tp := partitionOf(m)
+	pending := t.partitions[tp]
+	for i := range pending {
+		if pending[i].msg.Offset == m.Offset {
+			pending[i].done = true
+			break
+		}
+	}
+
+	var (
+		commit kafka.Message
+		n      int
+	)
+	for n < len(pending) && pending[n].done {
+		commit = pending[n].msg
+		n++
+	}
+	if n == 0 {
+		return kafka.Message{}, false
+	}
+
+	if n == len(pending) {
+		delete(t.partitions, tp)
+	} else {
+		t.partitions[tp] = pending[n:]
+	}
+	return commit, true
+}
+
+// pending возвращает число незафиксированных сообщений партиции.
+func (t *offsetTracker) pending(tp topicPartition) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.partitions[tp])
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func msgAt(partition int, offset int64) kafka.Message {
+	return kafka.Message{Topic: "t", Partition: partition, Offset: offset}
+}
+
+func TestOffsetTrackerDone(t *testing.T) {
+	tests := []struct {
+		name string
+		// done — оффсеты партиции 0 в порядке завершения обработки.
+		done []int64
+		// want — ожидаемый оффсет коммита после каждого done; -1 — коммитить нечего.
+		want        []int64
+		wantPending int
+	}{
+		{
+			name:        "in order",
+			done:        []int64{0, 1, 2, 3},
+			want:        []int64{0, 1, 2, 3},
+			wantPending: 0,
+		},
+		{
+			name:        "out of order",
+			done:        []int64{2, 1, 3, 0},
+			want:        []int64{-1, -1, -1, 3},
+			wantPending: 0,
+		},
+		{
+			name:        "permanent failure blocks later offsets",
+			done:        []int64{0, 2, 3},
+			want:        []int64{0, -1, -1},
+			wantPending: 3,
+		},
+		{
+			name:        "repeated done is idempotent",
+			done:        []int64{1, 1, 0},
+			want:        []int64{-1, -1, 1},
+			wantPending: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newOffsetTracker()
+			for off := range int64(4) {
+				tr.track(msgAt(0, off))
+			}
+
+			for i, off := range tt.done {
+				commit, ok := tr.done(msgAt(0, off))
+				switch {
+				case tt.want[i] < 0 && ok:
+					t.Fatalf("done(%d): unexpected commit at %d", off, commit.Offset)
+				case tt.want[i] >= 0 && (!ok || commit.Offset != tt.want[i]):
+					t.Fatalf("done(%d) = %d, %t; want %d", off, commit.Offset, ok, tt.want[i])
+				}
+			}
+
+			if got := tr.pending(topicPartition{topic: "t"}); got != tt.wantPending {
+				t.Fatalf("pending = %d, want %d", got, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerPartitionsAreIndependent(t *testing.T) {
+	tr := newOffsetTracker()
+	tr.track(msgAt(0, 10))
+	tr.track(msgAt(1, 20))
+	tr.track(msgAt(0, 11))
+
+	if _, ok := tr.done(msgAt(0, 11)); ok {
+		t.Fatal("offset 11 committed before 10")
+	}
+	if commit, ok := tr.done(msgAt(1, 20)); !ok || commit.Partition != 1 || commit.Offset != 20 {
+		t.Fatalf("partition 1 commit = %+v, %t", commit, ok)
+	}
+	if commit, ok := tr.done(msgAt(0, 10)); !ok || commit.Offset != 11 {
+		t.Fatalf("partition 0 commit = %d, %t; want 11", commit.Offset, ok)
+	}
+	if n := len(tr.partitions); n != 0 {
+		t.Fatalf("drained partitions kept: %d", n)
+	}
+}
+
+func TestOffsetTrackerDuplicateOffset(t *testing.T) {
+	tr := newOffsetTracker()
+	for off := range int64(3) {
+		if tr.track(msgAt(0, off)) {
+			t.Fatalf("track(%d) reported rewind", off)
+		}
+	}
+
+	// Оффсет 1 закончил обработку, 0 — нет; после перебалансировки партиция
+	// читается заново с 1 (оффсет 0 зафиксирован другим участником группы).
+	if _, ok := tr.done(msgAt(0, 1)); ok {
+		t.Fatal("offset 1 committed before 0")
+	}
+	if !tr.track(msgAt(0, 1)) {
+		t.Fatal("duplicate offset not reported as rewind")
+	}
+	if got := tr.pending(topicPartition{topic: "t"}); got != 2 {
+		t.Fatalf("pending after rewind = %d, want 2 (offsets 0 and 1)", got)
+	}
+
+	// Повторно прочитанный оффсет 1 ожидает обработки заново.
+	if _, ok := tr.done(msgAt(0, 0)); !ok {
+		t.Fatal("offset 0 not committable")
+	}
+	if commit, ok := tr.done(msgAt(0, 1)); !ok || commit.Offset != 1 {
+		t.Fatalf("commit = %d, %t; want 1", commit.Offset, ok)
+	}
+
+	// Сообщение, отброшенное при перемотке, не влияет на коммиты.
+	if _, ok := tr.done(msgAt(0, 2)); ok {
+		t.Fatal("stale offset 2 committed")
+	}
+}
@@ -0,0 +1,190 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/pkg/retry"
+	"github.com/segmentio/kafka-go"
+)
+
+// partitionQueueSize — емкость очереди одного воркера. Когда очередь заполнена,
+// чтение из Kafka приостанавливается.
+const partitionQueueSize = 64
+
+// partitionPool распределяет сообщения по воркерам так, что каждая партиция
+// закреплена ровно за одним воркером: порядок внутри партиции сохраняется,
+// а разные партиции обрабатываются параллельно. Партиция, все сообщения
+// которой обработаны и зафиксированы, открепляется от воркера.
+type partitionPool struct {
+	k       *Kafka
+	size    int
+	tracker *offsetTracker
+	// fail останавливает консюмер с причиной ErrPartitionBlocked.
+	fail context.CancelCauseFunc
+
+	// mu согласует регистрацию сообщений в tracker с закреплением партиций.
+	mu      sync.Mutex
+	workers []chan kafka.Message
+	load    []int
+	assign  map[topicPartition]int
+
+	wg sync.WaitGroup
+}
+
+// consumePartitioned читает сообщения и раздает их не более чем workers
+// горутинам. Завершается после остановки чтения и обработки текущих сообщений.
+// Если сообщение не удалось ни обработать, ни отправить в DLQ, оффсеты его
+// партиции больше не могут продвинуться: чтение останавливается и
+// возвращается ошибка ErrPartitionBlocked.
+func (k *Kafka) consumePartitioned(ctx context.Context, workers int) error {
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+
+	pool := &partitionPool{
+		k:       k,
+		size:    workers,
+		tracker: newOffsetTracker(),
+		fail:    fail,
+		assign:  make(map[topicPartition]int),
+	}
+
+	backoff := retry.NewBackoff(k.deps.Retry.Policy(config.RetryKafkaFetch))
+
+	for {
+		msg, ok := k.next(ctx, backoff)
+		if !ok || !pool.dispatch(ctx, msg) {
+			break
+		}
+	}
+	pool.stop()
+
+	return blockedCause(ctx)
+}
+
+// blockedCause возвращает причину остановки, если консюмер остановлен
+// из-за заблокированной партиции.
+func blockedCause(ctx context.Context) error {
+	if err := context.Cause(ctx); errors.Is(err, ErrPartitionBlocked) {
+		return err
+	}
+	return nil
+}
+
+// dispatch регистрирует сообщение и передает его воркеру его партиции. Новая
+// партиция получает собственный воркер, пока не достигнут лимит, затем —
+// наименее загруженный.
+func (p *partitionPool) dispatch(ctx context.Context, m kafka.Message) bool {
+	tp := partitionOf(m)
+
+	p.mu.Lock()
+	rewound := p.tracker.track(m)
+	idx, ok := p.assign[tp]
+	if !ok {
+		idx = p.pick(ctx)
+		p.assign[tp] = idx
+		p.load[idx]++
+	}
+	p.mu.Unlock()
+
+	if rewound {
+		p.k.deps.Log.Debug("partition rewound, stale offsets dropped",
+			"topic", m.Topic, "partition", m.Partition, "offset", m.Offset)
+	}
+
+	select {
+	case p.workers[idx] <- m:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// pick выбирает воркер для новой партиции. Вызывается под p.mu.
+func (p *partitionPool) pick(ctx context.Context) int {
+	if len(p.workers) < p.size {
+		ch := make(chan kafka.Message, partitionQueueSize)
+		p.workers = append(p.workers, ch)
+		p.load = append(p.load, 0)
+
+		p.wg.Add(1)
+		go p.run(ctx, ch)
+
+		return len(p.workers) - 1
+	}
+
+	idx := 0
+	for i := range p.load {
+		if p.load[i] < p.load[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// release открепляет партицию от воркера, если у нее не осталось
+// незафиксированных сообщений. Вызывается после коммита, поэтому следующий
+// воркер партиции не зафиксирует оффсет раньше текущего.
+func (p *partitionPool) release(tp topicPartition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.tracker.pending(tp) > 0 {
+		return
+	}
+	if idx, ok := p.assign[tp]; ok {
+		delete(p.assign, tp)
+		p.load[idx]--
+	}
+}
+
+// run последовательно обрабатывает сообщения закрепленных за воркером партиций.
+// После остановки консюмера (ctx отменен) оставшиеся в очереди сообщения
+// пропускаются без коммита и будут доставлены повторно.
+func (p *partitionPool) run(ctx context.Context, queue <-chan kafka.Message) {
+	defer p.wg.Done()
+
+	for msg := range queue {
+		p.handle(ctx, msg)
+	}
+}
+
+func (p *partitionPool) handle(ctx context.Context, msg kafka.Message) {
+	k := p.k
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if !k.process(ctx, msg) {
+		if ctx.Err() == nil {
+			p.fail(blockedBy(msg))
+		}
+		return
+	}
+
+	commit, ok := p.tracker.done(msg)
+	if !ok {
+		return
+	}
+	defer p.release(partitionOf(commit))
+
+	if err := k.commitWithRetry(context.WithoutCancel(ctx), commit); err != nil {
+		k.deps.Log.Error("commit failed", "err", err,
+			"topic", commit.Topic, "partition", commit.Partition, "offset", commit.Offset)
+		return
+	}
+	k.deps.Metrics.KafkaCommitted(commit.Topic, commit.Partition)
+	k.deps.Log.Debug("message committed", "topic", commit.Topic, "partition", commit.Partition,
+		"offset", commit.Offset, "pending", p.tracker.pending(partitionOf(commit)))
+}
+
+// stop закрывает очереди воркеров и ожидает их завершения.
+func (p *partitionPool) stop() {
+	for _, ch := range p.workers {
+		close(ch)
+	}
+	p.wg.Wait()
+}
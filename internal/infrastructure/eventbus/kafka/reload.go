@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"slices"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+)
+
+// reloadablePaths перечисляет параметры конфигурации, которые ApplyConfig
+// применяет без перезапуска консюмера.
+var reloadablePaths = []string{
+	"kafka.batch.size",
+	"kafka.batch.timeout",
+	"kafka.dlq.max-attempts",
+}
+
+// tunables — снимок параметров, изменяемых на лету. Снимок заменяется целиком,
+// поэтому воркеры читают согласованные значения без блокировок.
+type tunables struct {
+	batchSize    int
+	batchTimeout time.Duration
+	maxAttempts  int
+}
+
+func newTunables(cfg *config.Config) *tunables {
+	return &tunables{
+		batchSize:    cfg.Batch.Size,
+		batchTimeout: cfg.Batch.Timeout,
+		maxAttempts:  cfg.DLQ.MaxAttempts,
+	}
+}
+
+// ReloadablePaths возвращает параметры конфигурации, которые можно менять на лету.
+func (k *Kafka) ReloadablePaths() []string { return slices.Clone(reloadablePaths) }
+
+// ApplyConfig применяет новые значения ReloadablePaths. Уже начатые паузы и
+// пачки дорабатывают со старыми значениями, следующие используют новые.
+func (k *Kafka) ApplyConfig(cfg *config.Config) {
+	k.tunables.Store(newTunables(cfg))
+}
+
+func (k *Kafka) tuned() *tunables { return k.tunables.Load() }
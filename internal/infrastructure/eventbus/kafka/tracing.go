@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/devoraq/AVQ_message_store/internal/infrastructure/eventbus/kafka"
+
+// headerCarrier позволяет пропагатору OpenTelemetry читать и писать
+// контекст трассировки (traceparent, tracestate, baggage) в заголовки сообщения.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+// Get возвращает значение первого заголовка с ключом key.
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set заменяет значение заголовка key или добавляет новый заголовок.
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys возвращает ключи всех заголовков.
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// messageContext извлекает из заголовков сообщения контекст трассировки
+// продюсера. Без заголовков возвращается исходный ctx.
+func messageContext(ctx context.Context, m kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &m.Headers})
+}
+
+// injectContext записывает контекст трассировки из ctx в заголовки сообщения.
+func injectContext(ctx context.Context, m *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &m.Headers})
+}
+
+func tracer() trace.Tracer { return otel.Tracer(tracerName) }
+
+// messageAttributes описывает координаты сообщения в терминах семантических
+// соглашений OpenTelemetry для систем обмена сообщениями.
+func messageAttributes(m kafka.Message) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKafka,
+		semconv.MessagingDestinationName(m.Topic),
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(m.Partition)),
+		semconv.MessagingKafkaMessageOffset(int(m.Offset)),
+	}
+}
+
+// linksTo возвращает ссылки на контексты продюсеров сообщений: пакетные
+// операции не могут иметь нескольких родителей, поэтому связываются с ними так.
+func linksTo(msgs []kafka.Message) []trace.Link {
+	links := make([]trace.Link, 0, len(msgs))
+	for _, m := range msgs {
+		sc := trace.SpanContextFromContext(messageContext(context.Background(), m))
+		if sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	return links
+}
+
+// endSpan отмечает ошибку в спане, если она есть, и завершает его.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,12 @@
+package logger
+
+import "errors"
+
+var (
+	// ErrUnknownFormat означает, что формат логов не поддерживается.
+	ErrUnknownFormat = errors.New("logger: unknown format")
+	// ErrOpenOutput сообщает, что не удалось открыть файл для логов.
+	ErrOpenOutput = errors.New("logger: open output")
+	// ErrBadPattern означает некорректный шаблон ключа для скрытия значений.
+	ErrBadPattern = errors.New("logger: bad redact pattern")
+)
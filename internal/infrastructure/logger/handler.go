@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поддерживаемые форматы логов.
+const (
+	// FormatPretty — цветной многострочный вывод для разработки (PrettyHandler).
+	FormatPretty = "pretty"
+	// FormatJSON — по объекту JSON на строку: time, level, msg, затем атрибуты
+	// записи (op, component и т.д.) и trace_id/span_id активного спана.
+	FormatJSON = "json"
+	// FormatLogfmt — строки key=value с теми же полями, что и FormatJSON.
+	FormatLogfmt = "logfmt"
+)
+
+// Имена полей контекста трассировки.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Formats перечисляет поддерживаемые форматы.
+var Formats = []string{FormatPretty, FormatJSON, FormatLogfmt}
+
+// NewHandler создает обработчик логов в формате format (пустой означает
+// FormatPretty). Записи, сделанные с контекстом активного спана
+// (InfoContext и т.п.), дополняются полями TraceIDKey и SpanIDKey.
+func NewHandler(out io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+
+	var h slog.Handler
+	switch format {
+	case FormatPretty, "":
+		h = NewPrettyHandler(out, PrettyHandlerOptions{Opts: *opts})
+	case FormatJSON:
+		h = slog.NewJSONHandler(out, opts)
+	case FormatLogfmt:
+		h = slog.NewTextHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	return &traceHandler{Handler: h}, nil
+}
+
+// OpenOutput открывает приемник логов: "stdout" (или пустая строка),
+// "stderr" либо путь к файлу, в который записи дописываются.
+func OpenOutput(name string) (io.WriteCloser, error) {
+	switch name {
+	case "", "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenOutput, err)
+	}
+	return f, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// traceHandler добавляет к записи идентификаторы активного спана из контекста.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String(TraceIDKey, sc.TraceID().String()),
+			slog.String(SpanIDKey, sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r) //nolint:wrapcheck // прозрачная обертка обработчика
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -1,5 +1,5 @@
-// Package logger предоставляет адаптер, который выводит структурированные логи slog
-// в читабельном текстовом виде.
+// Package logger предоставляет обработчики slog: читабельный текстовый
+// PrettyHandler для разработки и машиночитаемые JSON и logfmt (см. NewHandler).
 package logger
 
 import (
@@ -9,6 +9,10 @@ import (
 	"io"
 	"log"
 	"log/slog"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -19,49 +23,72 @@ type PrettyHandlerOptions struct {
 	Opts slog.HandlerOptions
 }
 
+// timeLayout — формат времени в заголовке записи.
+const timeLayout = "15:04:05.000"
+
 // PrettyHandler реализует интерфейс slog.Handler и печатает записи в компактном виде.
+// Поддерживает slog.HandlerOptions целиком: Level (включая *slog.LevelVar),
+// AddSource и ReplaceAttr.
 type PrettyHandler struct {
-	l      *log.Logger
-	attrs  []slog.Attr
+	l         *log.Logger
+	level     slog.Leveler
+	addSource bool
+	replace   func(groups []string, a slog.Attr) slog.Attr
+	// attrs уже развернуты с учетом групп, действовавших при WithAttrs.
+	attrs  []attrPair
 	groups []string
 }
 
-// Enabled всегда возвращает true, предоставляя slog.Logger решать, писать ли запись.
-func (h *PrettyHandler) Enabled(_ context.Context, _ slog.Level) bool {
-	return true
+// Enabled сообщает, не ниже ли уровень записи минимального (Opts.Level).
+// Если Opts.Level — *slog.LevelVar, уровень можно менять на лету.
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+	return level >= minLevel
 }
 
 // Handle форматирует запись slog с подсветкой уровня и печатает её.
+// Встроенные поля (время, уровень, сообщение, источник) проходят через
+// ReplaceAttr так же, как в обработчиках slog; поле с пустым ключом не выводится.
 func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	var b strings.Builder
 	b.Grow(256)
 
-	level := r.Level.String() + ":"
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.GreenString(level)
-	case slog.LevelWarn:
-		level = color.YellowString(level)
-	case slog.LevelError:
-		level = color.RedString(level)
+	if !r.Time.IsZero() {
+		if a, ok := h.builtin(slog.Time(slog.TimeKey, r.Time)); ok {
+			b.WriteByte('[')
+			if a.Value.Kind() == slog.KindTime {
+				b.WriteString(a.Value.Time().Format(timeLayout))
+			} else {
+				b.WriteString(a.Value.String())
+			}
+			b.WriteString("] ")
+		}
 	}
 
-	b.WriteString(r.Time.Format("[15:05:05.000] "))
-	b.WriteString(level)
-	b.WriteByte(' ')
-	b.WriteString(color.WhiteString(r.Message))
+	if a, ok := h.builtin(slog.Any(slog.LevelKey, r.Level)); ok {
+		b.WriteString(formatLevel(a.Value))
+		b.WriteByte(' ')
+	}
 
-	prefix := strings.Join(h.groups, ".")
-	pairs := make([]attrPair, 0, len(h.attrs)+r.NumAttrs())
+	if a, ok := h.builtin(slog.String(slog.MessageKey, r.Message)); ok {
+		b.WriteString(color.WhiteString(a.Value.String()))
+	}
 
-	for _, a := range h.attrs {
-		flattenAttr(prefix, a, &pairs)
+	if h.addSource && r.PC != 0 {
+		if a, ok := h.builtin(slog.Any(slog.SourceKey, recordSource(r.PC))); ok {
+			b.WriteByte(' ')
+			b.WriteString(color.HiBlackString("(" + formatSource(a.Value) + ")"))
+		}
 	}
 
+	pairs := make([]attrPair, 0, len(h.attrs)+r.NumAttrs())
+	pairs = append(pairs, h.attrs...)
+
 	r.Attrs(func(a slog.Attr) bool {
-		flattenAttr(prefix, a, &pairs)
+		h.appendAttr(h.groups, a, &pairs)
 		return true
 	})
 
@@ -100,7 +127,7 @@ func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	}
 	clone := h.clone()
 	for _, a := range attrs {
-		clone.attrs = append(clone.attrs, resolveAttr(a))
+		clone.appendAttr(clone.groups, a, &clone.attrs)
 	}
 	return clone
 }
@@ -121,24 +148,32 @@ func NewPrettyHandler(
 	out io.Writer,
 	opts PrettyHandlerOptions,
 ) *PrettyHandler {
-	_ = opts
 	h := &PrettyHandler{
-		l: log.New(out, "", 0),
+		l:         log.New(out, "", 0),
+		level:     opts.Opts.Level,
+		addSource: opts.Opts.AddSource,
+		replace:   opts.Opts.ReplaceAttr,
 	}
 
 	return h
 }
 
 func (h *PrettyHandler) clone() *PrettyHandler {
-	attrs := make([]slog.Attr, len(h.attrs))
-	copy(attrs, h.attrs)
-	groups := make([]string, len(h.groups))
-	copy(groups, h.groups)
-	return &PrettyHandler{
-		l:      h.l,
-		attrs:  attrs,
-		groups: groups,
+	clone := *h
+	clone.attrs = slices.Clone(h.attrs)
+	clone.groups = slices.Clone(h.groups)
+	return &clone
+}
+
+// builtin применяет ReplaceAttr к встроенному полю записи. Возвращает false,
+// если поле нужно пропустить.
+func (h *PrettyHandler) builtin(a slog.Attr) (slog.Attr, bool) {
+	if h.replace == nil {
+		return a, true
 	}
+	a = h.replace(nil, a)
+	a.Value = a.Value.Resolve()
+	return a, a.Key != ""
 }
 
 type attrPair struct {
@@ -146,43 +181,92 @@ type attrPair struct {
 	value any
 }
 
-func flattenAttr(prefix string, attr slog.Attr, out *[]attrPair) {
-	value := attr.Value.Resolve()
+// appendAttr разворачивает атрибут в пары ключ-значение с ключами вида
+// "группа.ключ", применяя ReplaceAttr к каждому атрибуту, кроме групп.
+func (h *PrettyHandler) appendAttr(groups []string, attr slog.Attr, out *[]attrPair) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
 
-	if value.Kind() == slog.KindGroup {
-		nextPrefix := prefix
+	if attr.Value.Kind() == slog.KindGroup {
+		children := attr.Value.Group()
+		if len(children) == 0 {
+			return
+		}
 		if attr.Key != "" {
-			if nextPrefix != "" {
-				nextPrefix += "."
-			}
-			nextPrefix += attr.Key
+			groups = append(groups[:len(groups):len(groups)], attr.Key)
 		}
-		for _, child := range value.Group() {
-			flattenAttr(nextPrefix, child, out)
+		for _, child := range children {
+			h.appendAttr(groups, child, out)
 		}
 		return
 	}
 
-	key := attr.Key
-	if prefix != "" && key != "" {
-		key = prefix + "." + key
-	} else if prefix != "" && key == "" {
-		key = prefix
+	if h.replace != nil {
+		attr = h.replace(groups, attr)
+		attr.Value = attr.Value.Resolve()
+		// ReplaceAttr с пустым ключом удаляет атрибут.
+		if attr.Key == "" {
+			return
+		}
+		if attr.Value.Kind() == slog.KindGroup {
+			h.appendAttr(groups, attr, out)
+			return
+		}
 	}
+
+	key := attr.Key
 	if key == "" {
 		key = "<root>"
 	}
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
 	*out = append(*out, attrPair{
 		key:   key,
-		value: value.Any(),
+		value: attr.Value.Any(),
 	})
 }
 
-func resolveAttr(a slog.Attr) slog.Attr {
-	return slog.Attr{
-		Key:   a.Key,
-		Value: a.Value.Resolve(),
+// formatLevel печатает уровень с подсветкой. Значение, замененное через
+// ReplaceAttr на что-то кроме slog.Level, выводится как есть.
+func formatLevel(v slog.Value) string {
+	level, ok := v.Any().(slog.Level)
+	if !ok {
+		return v.String() + ":"
+	}
+
+	text := level.String() + ":"
+	switch {
+	case level < slog.LevelInfo:
+		return color.MagentaString(text)
+	case level < slog.LevelWarn:
+		return color.GreenString(text)
+	case level < slog.LevelError:
+		return color.YellowString(text)
+	default:
+		return color.RedString(text)
+	}
+}
+
+func recordSource(pc uintptr) *slog.Source {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &slog.Source{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+	}
+}
+
+// formatSource печатает источник как "каталог/файл.go:строка".
+func formatSource(v slog.Value) string {
+	src, ok := v.Any().(*slog.Source)
+	if !ok || src == nil {
+		return v.String()
 	}
+	dir, file := filepath.Split(src.File)
+	return filepath.Join(filepath.Base(dir), file) + ":" + strconv.Itoa(src.Line)
 }
 
 func formatAttrValue(v any) []string {
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// RedactedValue подставляется вместо скрытых значений.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys — шаблоны ключей, значения которых скрываются всегда.
+// Шаблоны сравниваются без учета регистра по правилам path.Match.
+var DefaultRedactKeys = []string{
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*token*",
+	"authorization",
+	"cookie",
+	"api_key",
+	"payload",
+	"body",
+}
+
+// Secret — строка, которая никогда не попадает в логи и вывод fmt: вместо
+// значения печатается RedactedValue. Исходное значение возвращает Reveal.
+type Secret string
+
+// LogValue реализует slog.LogValuer.
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.mask()) }
+
+// String реализует fmt.Stringer.
+func (s Secret) String() string { return s.mask() }
+
+// GoString скрывает значение и в формате %#v.
+func (s Secret) GoString() string { return s.mask() }
+
+// Reveal возвращает исходное значение секрета.
+func (s Secret) Reveal() string { return string(s) }
+
+// mask сохраняет различие между пустым и заданным секретом.
+func (s Secret) mask() string {
+	if s == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// Redactor скрывает значения атрибутов, ключ которых совпадает с одним из
+// шаблонов. Шаблон проверяется по ключу атрибута, по полному пути вида
+// "группа.ключ" и по именам объемлющих групп: шаблон "payload" скрывает и
+// slog.Group("payload", ...) целиком.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor создает Redactor с шаблонами DefaultRedactKeys и patterns.
+// Возвращает ErrBadPattern, если шаблон некорректен.
+func NewRedactor(patterns ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range slices.Concat(DefaultRedactKeys, patterns) {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if err := ValidatePattern(p); err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, p)
+	}
+	return r, nil
+}
+
+// ValidatePattern проверяет синтаксис шаблона ключа.
+func ValidatePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%w: %q", ErrBadPattern, pattern)
+	}
+	return nil
+}
+
+// ReplaceAttr возвращает функцию для slog.HandlerOptions.ReplaceAttr, которая
+// сначала скрывает значения, а затем вызывает next (если он задан).
+func (r *Redactor) ReplaceAttr(next func(groups []string, a slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		// Встроенные поля записи (время, уровень, сообщение) не скрываются.
+		if len(groups) > 0 || !isBuiltinKey(a.Key) {
+			if r.Match(groups, a.Key) {
+				a = slog.String(a.Key, RedactedValue)
+			}
+		}
+		if next != nil {
+			a = next(groups, a)
+		}
+		return a
+	}
+}
+
+// Match сообщает, нужно ли скрыть значение атрибута key внутри групп groups.
+func (r *Redactor) Match(groups []string, key string) bool {
+	if r.matches(key) {
+		return true
+	}
+	if len(groups) == 0 {
+		return false
+	}
+	for _, g := range groups {
+		if r.matches(g) {
+			return true
+		}
+	}
+	return r.matches(strings.Join(groups, ".") + "." + key)
+}
+
+func (r *Redactor) matches(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range r.patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltinKey(key string) bool {
+	switch key {
+	case slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// OpKey — имя атрибута с названием операции, по которому группируются
+// одинаковые записи (см. SamplingHandler).
+const OpKey = "op"
+
+// sampleExemptKeys — атрибуты, которые описывают конкретное сообщение
+// (оффсет, ключ): такие записи не бывают одинаковыми и не ограничиваются.
+var sampleExemptKeys = map[string]bool{"offset": true, "key": true}
+
+// SampleOptions задает ограничение частоты одинаковых записей.
+type SampleOptions struct {
+	// Interval — окно, в котором считаются одинаковые записи. Ноль отключает
+	// ограничение: выводятся все записи.
+	Interval time.Duration
+	// Burst — сколько одинаковых записей за окно выводится; остальные
+	// подавляются. Значение меньше 1 означает 1.
+	Burst int
+}
+
+// SamplingHandler ограничивает частоту одинаковых записей: записи с тем же
+// уровнем, сообщением и атрибутом OpKey сверх Burst за Interval не выводятся.
+// По истечении окна вместо подавленных записей выводится одна сводная
+// "suppressed N similar messages". Сводки по окнам, в которых записи
+// перестали поступать, выводит Run. Записи уровня Error и выше, а также
+// записи с атрибутами offset или key выводятся всегда.
+type SamplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+	// op — значение OpKey, добавленное через WithAttrs вне групп.
+	op string
+	// exempt — через WithAttrs добавлен атрибут из sampleExemptKeys.
+	exempt  bool
+	grouped bool
+}
+
+// NewSamplingHandler оборачивает next ограничением частоты одинаковых записей.
+func NewSamplingHandler(next slog.Handler, opts SampleOptions) *SamplingHandler {
+	return &SamplingHandler{
+		next: next,
+		sampler: &sampler{
+			interval: opts.Interval,
+			burst:    max(opts.Burst, 1),
+			now:      time.Now,
+			windows:  make(map[sampleKey]*sampleWindow),
+		},
+	}
+}
+
+// sampleKey определяет, какие записи считаются одинаковыми.
+type sampleKey struct {
+	level slog.Level
+	msg   string
+	op    string
+}
+
+// sampleWindow — счетчики одинаковых записей за текущее окно.
+type sampleWindow struct {
+	start      time.Time
+	passed     int
+	suppressed int
+	// next — обработчик первой записи окна, через него выводится сводка,
+	// чтобы она сохранила атрибуты логгера (component и т.п.).
+	next slog.Handler
+	// op — значение OpKey, если оно было в самой записи, а не в атрибутах логгера.
+	op string
+}
+
+// sampler хранит окна, общие для всех производных обработчиков.
+type sampler struct {
+	interval time.Duration
+	burst    int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	windows map[sampleKey]*sampleWindow
+}
+
+// summary — сводка о подавленных записях закрытого окна.
+type summary struct {
+	key        sampleKey
+	suppressed int
+	next       slog.Handler
+	op         string
+}
+
+// Enabled делегирует проверку уровня обернутому обработчику.
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle выводит запись, если лимит одинаковых записей в текущем окне не
+// исчерпан, и сводку по предыдущему окну, если в нем были подавленные записи.
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	op, inRecord, exempt := h.inspect(r)
+	if exempt || r.Level >= slog.LevelError {
+		return h.next.Handle(ctx, r) //nolint:wrapcheck // прозрачная обертка обработчика
+	}
+	key := sampleKey{level: r.Level, msg: r.Message, op: op}
+	if !inRecord {
+		op = ""
+	}
+
+	allow, closed := h.sampler.admit(key, h.next, op)
+	if closed != nil {
+		h.sampler.emit(ctx, *closed)
+	}
+	if !allow {
+		return nil
+	}
+	return h.next.Handle(ctx, r) //nolint:wrapcheck // прозрачная обертка обработчика
+}
+
+// WithAttrs возвращает обработчик с атрибутами; окна остаются общими.
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == OpKey {
+				clone.op = a.Value.Resolve().String()
+			}
+			if sampleExemptKeys[a.Key] {
+				clone.exempt = true
+			}
+		}
+	}
+	return &clone
+}
+
+// WithGroup возвращает обработчик с группой; окна остаются общими.
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.grouped = true
+	return &clone
+}
+
+// Run выводит сводки по окнам, которые истекли без новых записей, с периодом
+// Interval, пока не отменен ctx. Перед возвратом выводит сводки по всем
+// окнам с подавленными записями.
+func (h *SamplingHandler) Run(ctx context.Context) error {
+	if h.sampler.interval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(h.sampler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.sampler.flush(true)
+			return nil
+		case <-ticker.C:
+			h.sampler.flush(false)
+		}
+	}
+}
+
+// inspect возвращает значение OpKey из записи или из атрибутов логгера,
+// сообщает, взято ли оно из записи, и освобождена ли запись от ограничения
+// (см. sampleExemptKeys). Атрибуты внутри групп не учитываются.
+func (h *SamplingHandler) inspect(r slog.Record) (op string, inRecord, exempt bool) {
+	op, exempt = h.op, h.exempt
+	if h.grouped {
+		return op, false, exempt
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		switch {
+		case a.Key == OpKey:
+			op, inRecord = a.Value.Resolve().String(), true
+		case sampleExemptKeys[a.Key]:
+			exempt = true
+		}
+		return true
+	})
+	return op, inRecord, exempt
+}
+
+// admit учитывает запись в окне key и сообщает, выводить ли ее. Если
+// предыдущее окно истекло с подавленными записями, возвращает сводку по нему.
+func (s *sampler) admit(key sampleKey, next slog.Handler, op string) (bool, *summary) {
+	if s.interval <= 0 {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var closed *summary
+	w, ok := s.windows[key]
+	switch {
+	case !ok:
+		w = &sampleWindow{start: now, next: next, op: op}
+		s.windows[key] = w
+	case now.Sub(w.start) >= s.interval:
+		if w.suppressed > 0 {
+			closed = &summary{key: key, suppressed: w.suppressed, next: w.next, op: w.op}
+		}
+		*w = sampleWindow{start: now, next: next, op: op}
+	}
+
+	if w.passed < s.burst {
+		w.passed++
+		return true, closed
+	}
+	w.suppressed++
+	return false, closed
+}
+
+// flush выводит сводки по истекшим окнам и удаляет их. При all выводятся
+// сводки по всем окнам.
+func (s *sampler) flush(all bool) {
+	s.mu.Lock()
+	now := s.now()
+	var closed []summary
+	for key, w := range s.windows {
+		if !all && now.Sub(w.start) < s.interval {
+			continue
+		}
+		if w.suppressed > 0 {
+			closed = append(closed, summary{key: key, suppressed: w.suppressed, next: w.next, op: w.op})
+		}
+		delete(s.windows, key)
+	}
+	s.mu.Unlock()
+
+	for _, c := range closed {
+		s.emit(context.Background(), c)
+	}
+}
+
+// emit выводит сводку о подавленных записях с уровнем исходных записей.
+func (s *sampler) emit(ctx context.Context, c summary) {
+	if !c.next.Enabled(ctx, c.key.level) {
+		return
+	}
+	msg := fmt.Sprintf("suppressed %d similar messages", c.suppressed)
+	r := slog.NewRecord(s.now(), c.key.level, msg, 0)
+	r.AddAttrs(
+		slog.Int("suppressed", c.suppressed),
+		slog.String("sampled_msg", c.key.msg),
+		slog.Duration("interval", s.interval),
+	)
+	if c.op != "" {
+		r.AddAttrs(slog.String(OpKey, c.op))
+	}
+	_ = c.next.Handle(ctx, r)
+}
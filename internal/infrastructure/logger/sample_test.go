@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, nil), SampleOptions{Interval: time.Minute, Burst: 1})
+	now := time.Unix(0, 0)
+	h.sampler.now = func() time.Time { return now }
+	log := slog.New(h)
+
+	// Уровни и атрибуты — как у записей консюмера Kafka.
+	for range 3 {
+		log.Warn("fetch failed", "err", "connection refused")
+		log.Error("handler failed", "topic", "t", "offset", 7)
+		log.Error("commit failed")
+	}
+	log.Warn("message moved to dlq", "topic", "t", "offset", 7)
+	log.With("key", "k").Warn("message moved to dlq")
+
+	now = now.Add(time.Minute)
+	log.Warn("fetch failed", "err", "connection refused")
+
+	out := buf.String()
+	for msg, want := range map[string]int{
+		" msg=\"fetch failed\"":                  2,
+		" msg=\"handler failed\"":                3,
+		" msg=\"commit failed\"":                 3,
+		" msg=\"message moved to dlq\"":          2,
+		" msg=\"suppressed 2 similar messages\"": 1,
+	} {
+		if got := strings.Count(out, msg); got != want {
+			t.Errorf("%s logged %d times, want %d\n%s", msg, got, want, out)
+		}
+	}
+	if !strings.Contains(out, "sampled_msg=\"fetch failed\"") {
+		t.Errorf("summary does not name sampled message\n%s", out)
+	}
+
+	// Сводка не выводится повторно при остановке.
+	buf.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = h.Run(ctx)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output on stop: %s", buf.String())
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute подставляется в метку route, если запрос не сопоставлен ни
+// одному шаблону мультиплексора: так неизвестные пути не раздувают кардинальность.
+const unmatchedRoute = "unmatched"
+
+// HTTPMiddleware измеряет длительность запросов. Метка route берется из
+// шаблона http.ServeMux, выбранного для запроса (Request.Pattern).
+func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.httpDuration.
+			WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder запоминает код ответа и пробрасывает Hijack для веб-сокетов.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck // прозрачная обертка ResponseWriter
+}
+
+// Hijack передает соединение вызывающему; код ответа считается 101.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("metrics: response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack() //nolint:wrapcheck // прозрачная обертка ResponseWriter
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
@@ -0,0 +1,212 @@
+// Package metrics содержит Prometheus-метрики сервиса и HTTP-обработчик для их экспорта.
+//
+// Все методы записи безопасно вызывать на nil-получателе: компоненты,
+// собранные без метрик, просто ничего не регистрируют.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "message_store"
+
+// Metrics хранит коллекторы сервиса и собственный реестр.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	kafkaFetched         *prometheus.CounterVec
+	kafkaHandled         *prometheus.CounterVec
+	kafkaFailed          *prometheus.CounterVec
+	kafkaCommitted       *prometheus.CounterVec
+	kafkaDLQ             *prometheus.CounterVec
+	kafkaHandlerDuration *prometheus.HistogramVec
+	kafkaCommitRetries   *prometheus.CounterVec
+
+	retryAttempts *prometheus.CounterVec
+	breakerState  *prometheus.GaugeVec
+
+	mongoDuration *prometheus.HistogramVec
+
+	httpDuration *prometheus.HistogramVec
+}
+
+// New создает метрики и регистрирует их вместе со стандартными коллекторами
+// процесса и рантайма Go.
+func New() *Metrics {
+	partitionLabels := []string{"topic", "partition"}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		kafkaFetched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "messages_fetched_total",
+			Help: "Messages fetched from Kafka.",
+		}, partitionLabels),
+		kafkaHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "messages_handled_total",
+			Help: "Messages successfully processed by delivery handlers.",
+		}, partitionLabels),
+		kafkaFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "messages_failed_total",
+			Help: "Messages whose handlers failed after all local attempts.",
+		}, partitionLabels),
+		kafkaCommitted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "messages_committed_total",
+			Help: "Offsets committed to Kafka.",
+		}, partitionLabels),
+		kafkaDLQ: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "messages_dlq_total",
+			Help: "Messages moved to the dead-letter topic.",
+		}, partitionLabels),
+		kafkaHandlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "handler_duration_seconds",
+			Help:    "Duration of delivery handler invocations.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic", "result"}),
+		kafkaCommitRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "kafka", Name: "commit_retries_total",
+			Help: "Failed offset commit attempts that were retried.",
+		}, []string{"topic"}),
+
+		retryAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "retry", Name: "attempts_total",
+			Help: "Failed attempts that were retried, by operation.",
+		}, []string{"operation"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "retry", Name: "breaker_state",
+			Help: "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+		}, []string{"breaker"}),
+
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "mongo", Name: "operation_duration_seconds",
+			Help:    "Duration of MongoDB commands.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+			Help:    "Duration of HTTP requests by route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.kafkaFetched,
+		m.kafkaHandled,
+		m.kafkaFailed,
+		m.kafkaCommitted,
+		m.kafkaDLQ,
+		m.kafkaHandlerDuration,
+		m.kafkaCommitRetries,
+		m.retryAttempts,
+		m.breakerState,
+		m.mongoDuration,
+		m.httpDuration,
+	)
+
+	return m
+}
+
+// Register регистрирует эндпоинт /metrics в переданном мультиплексоре.
+func (m *Metrics) Register(mux *http.ServeMux) {
+	mux.Handle("GET /metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Registry возвращает реестр для регистрации дополнительных коллекторов.
+func (m *Metrics) Registry() *prometheus.Registry { return m.registry }
+
+// KafkaFetched учитывает прочитанное сообщение.
+func (m *Metrics) KafkaFetched(topic string, partition int) {
+	if m == nil {
+		return
+	}
+	m.kafkaFetched.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// KafkaHandled учитывает успешно обработанное сообщение.
+func (m *Metrics) KafkaHandled(topic string, partition int) {
+	if m == nil {
+		return
+	}
+	m.kafkaHandled.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// KafkaFailed учитывает сообщение, обработка которого не удалась после всех попыток.
+func (m *Metrics) KafkaFailed(topic string, partition int) {
+	if m == nil {
+		return
+	}
+	m.kafkaFailed.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// KafkaCommitted учитывает зафиксированное сообщение.
+func (m *Metrics) KafkaCommitted(topic string, partition int) {
+	if m == nil {
+		return
+	}
+	m.kafkaCommitted.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// KafkaDLQ учитывает сообщение, отправленное в DLQ.
+func (m *Metrics) KafkaDLQ(topic string, partition int) {
+	if m == nil {
+		return
+	}
+	m.kafkaDLQ.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// KafkaHandlerDuration учитывает длительность вызова обработчиков.
+func (m *Metrics) KafkaHandlerDuration(topic string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.kafkaHandlerDuration.WithLabelValues(topic, result(err)).Observe(d.Seconds())
+}
+
+// KafkaCommitRetry учитывает неудачную попытку коммита, за которой последует повтор.
+func (m *Metrics) KafkaCommitRetry(topic string) {
+	if m == nil {
+		return
+	}
+	m.kafkaCommitRetries.WithLabelValues(topic).Inc()
+}
+
+// RetryAttempt учитывает неудачную попытку операции, за которой последует повтор.
+func (m *Metrics) RetryAttempt(operation string) {
+	if m == nil {
+		return
+	}
+	m.retryAttempts.WithLabelValues(operation).Inc()
+}
+
+// BreakerState фиксирует состояние предохранителя (0 — замкнут, 1 — разомкнут,
+// 2 — полуразомкнут).
+func (m *Metrics) BreakerState(breaker string, state int) {
+	if m == nil {
+		return
+	}
+	m.breakerState.WithLabelValues(breaker).Set(float64(state))
+}
+
+// MongoOperation учитывает длительность команды MongoDB.
+func (m *Metrics) MongoOperation(operation string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mongoDuration.WithLabelValues(operation, result(err)).Observe(d.Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/metrics"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
@@ -22,8 +23,9 @@ type MongoDB struct {
 
 // MongoDeps содержит зависимости, необходимые для инициализации MongoDB-клиента.
 type MongoDeps struct {
-	Cfg    *config.MongoConfig
-	Logger *slog.Logger
+	Cfg     *config.MongoConfig
+	Logger  *slog.Logger
+	Metrics *metrics.Metrics
 }
 
 // New создает клиент MongoDB по заданной конфигурации.
@@ -32,10 +34,11 @@ func New(deps *MongoDeps) (*MongoDB, error) {
 		Hosts: []string{deps.Cfg.Addr},
 		Auth: &options.Credential{
 			Username: deps.Cfg.Username,
-			Password: deps.Cfg.Password,
+			Password: deps.Cfg.Password.Reveal(),
 		},
 		ConnectTimeout: &deps.Cfg.ConnectTimeout,
 		MaxPoolSize:    &deps.Cfg.MaxPoolSize,
+		Monitor:        newCommandMonitor(deps.Metrics).monitor(),
 	}
 
 	client, err := mongo.Connect(&opts)
@@ -74,6 +77,14 @@ func (md *MongoDB) Start(ctx context.Context) error {
 	return nil
 }
 
+// HealthCheck проверяет, что primary-узел MongoDB отвечает на ping.
+func (md *MongoDB) HealthCheck(ctx context.Context) error {
+	if err := md.Ping(ctx, readpref.Primary()); err != nil {
+		return errors.Join(ErrPing, err)
+	}
+	return nil
+}
+
 // Stop корректно закрывает соединение с MongoDB.
 func (md *MongoDB) Stop(ctx context.Context) error {
 	log := md.deps.Logger.With(
@@ -0,0 +1,91 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/metrics"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/devoraq/AVQ_message_store/internal/infrastructure/nosql/mongodb"
+
+// commandMonitor передает длительность каждой команды MongoDB в метрики и
+// оформляет команду спаном трассировки. Спаны создаются только внутри уже
+// начатой трассы (например, обработки сообщения Kafka), чтобы пинги
+// health-check не порождали отдельных трасс.
+type commandMonitor struct {
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+
+	// spans хранит открытые спаны по RequestID команды.
+	spans sync.Map
+}
+
+func newCommandMonitor(m *metrics.Metrics) *commandMonitor {
+	return &commandMonitor{
+		metrics: m,
+		tracer:  otel.Tracer(tracerName),
+	}
+}
+
+func (cm *commandMonitor) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   cm.started,
+		Succeeded: cm.succeeded,
+		Failed:    cm.failed,
+	}
+}
+
+func (cm *commandMonitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemMongoDB,
+		semconv.DBNamespace(e.DatabaseName),
+		semconv.DBOperationName(e.CommandName),
+	}
+	name := e.CommandName
+	// Для команд над коллекцией первый элемент команды — имя коллекции.
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		attrs = append(attrs, semconv.DBCollectionName(collection))
+		name += " " + collection
+	}
+
+	_, span := cm.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	cm.spans.Store(e.RequestID, span)
+}
+
+func (cm *commandMonitor) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	cm.finish(e.RequestID, e.CommandName, e.Duration, nil)
+}
+
+func (cm *commandMonitor) failed(_ context.Context, e *event.CommandFailedEvent) {
+	cm.finish(e.RequestID, e.CommandName, e.Duration, e.Failure)
+}
+
+func (cm *commandMonitor) finish(requestID int64, command string, d time.Duration, err error) {
+	cm.metrics.MongoOperation(command, d, err)
+
+	v, ok := cm.spans.LoadAndDelete(requestID)
+	if !ok {
+		return
+	}
+	span, _ := v.(trace.Span)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
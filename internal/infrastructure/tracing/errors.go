@@ -0,0 +1,12 @@
+package tracing
+
+import "errors"
+
+var (
+	// ErrUnknownExporter означает, что в конфигурации указан неизвестный экспортер.
+	ErrUnknownExporter = errors.New("tracing: unknown exporter")
+	// ErrCreateExporter сообщает о сбое создания экспортера трасс.
+	ErrCreateExporter = errors.New("tracing: create exporter failed")
+	// ErrShutdown сообщает о неудачной выгрузке накопленных спанов при остановке.
+	ErrShutdown = errors.New("tracing: shutdown failed")
+)
@@ -0,0 +1,161 @@
+// Package tracing настраивает OpenTelemetry: глобальный провайдер трасс,
+// экспортер и пропагатор W3C Trace Context.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Поддерживаемые экспортеры трасс.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+const defaultServiceName = "message-store"
+
+// Tracing — компонент, владеющий провайдером трасс. Start регистрирует
+// глобальные провайдер и пропагатор, Stop выгружает накопленные спаны.
+// Его следует запускать раньше компонентов, создающих спаны, чтобы при
+// остановке он закрывался последним.
+type Tracing struct {
+	name     string
+	deps     *TracingDeps
+	provider *sdktrace.TracerProvider
+}
+
+// TracingDeps содержит зависимости компонента трассировки.
+// Cfg может быть nil — тогда трассы не экспортируются.
+//
+//nolint:revive // имя согласовано с *Deps других компонентов
+type TracingDeps struct {
+	Cfg *config.TracingConfig
+	Log *slog.Logger
+}
+
+// New создает компонент трассировки.
+func New(deps *TracingDeps) *Tracing {
+	if deps.Log == nil {
+		panic("Logger cannot be nil")
+	}
+	return &Tracing{
+		name: "tracing",
+		deps: deps,
+	}
+}
+
+// Name возвращает имя компонента.
+func (t *Tracing) Name() string { return t.name }
+
+// Start настраивает пропагатор и, если задан экспортер, провайдер трасс.
+// Пропагатор устанавливается всегда: даже без экспорта контекст трассировки
+// должен проходить от продюсера через Kafka дальше.
+func (t *Tracing) Start(ctx context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	cfg := t.config()
+	exporter, err := newExporter(ctx, &cfg)
+	if err != nil {
+		return err
+	}
+	if exporter == nil {
+		t.deps.Log.Debug("Tracing export disabled")
+		return nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return fmt.Errorf("tracing resource: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(t.provider)
+
+	t.deps.Log.Debug("Tracing started",
+		slog.String("exporter", cfg.Exporter),
+		slog.String("service", cfg.ServiceName),
+		slog.Float64("sample_ratio", cfg.SampleRatio),
+	)
+	return nil
+}
+
+// Stop выгружает накопленные спаны и останавливает провайдер.
+func (t *Tracing) Stop(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	if err := t.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrShutdown, err)
+	}
+	return nil
+}
+
+// config возвращает копию конфигурации с подставленными значениями по умолчанию.
+func (t *Tracing) config() config.TracingConfig {
+	var cfg config.TracingConfig
+	if t.deps.Cfg != nil {
+		cfg = *t.deps.Cfg
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterNone
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		cfg.SampleRatio = 1
+	}
+	return cfg
+}
+
+// newExporter создает экспортер по имени из конфигурации. Для ExporterNone
+// возвращает nil: глобальный провайдер остается no-op.
+func newExporter(ctx context.Context, cfg *config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCreateExporter, err)
+		}
+		return exporter, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCreateExporter, err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, cfg.Exporter)
+	}
+}
@@ -0,0 +1,310 @@
+// Package usecase содержит прикладные сценарии сервиса хранения сообщений.
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/devoraq/AVQ_message_store/internal/domain"
+)
+
+const (
+	defaultContentType = "text/plain"
+	// DefaultPageSize — размер страницы, если лимит не задан.
+	DefaultPageSize = 50
+	// MaxPageSize — наибольший допустимый размер страницы.
+	MaxPageSize = 200
+)
+
+// MessageUseCase реализует сценарий приёма сообщения: декодирование,
+// проверку инвариантов и идемпотентное сохранение в репозиторий.
+type MessageUseCase struct {
+	deps *MessageDeps
+	now  func() time.Time
+
+	duplicates atomic.Uint64
+}
+
+// MessageDeps содержит зависимости сценария работы с сообщениями.
+type MessageDeps struct {
+	Repo domain.MessageRepository
+	Log  *slog.Logger
+}
+
+// incomingMessage — формат сообщения, которое присылает отправитель.
+type incomingMessage struct {
+	ID          string    `json:"id"`
+	ChatID      string    `json:"chat_id"`
+	SenderID    string    `json:"sender_id"`
+	ContentType string    `json:"content_type"`
+	Payload     string    `json:"payload"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MessagePage — страница сообщений чата, упорядоченная от новых к старым.
+// HasMore сообщает, что в направлении запроса есть ещё сообщения.
+type MessagePage struct {
+	Messages []*domain.Message
+	HasMore  bool
+}
+
+// NewMessage описывает сообщение, переданное отправителем в структурированном
+// виде. ID — идентификатор отправителя, используемый для идемпотентности.
+type NewMessage struct {
+	ID          string
+	ChatID      string
+	SenderID    string
+	ContentType string
+	Payload     []byte
+	CreatedAt   time.Time
+	Source      domain.Source
+}
+
+// RawMessage — сырое сообщение вместе с координатами его источника.
+type RawMessage struct {
+	Data   []byte
+	Source domain.Source
+}
+
+// NewMessageUseCase создает сценарий работы с сообщениями.
+// Паника возникает, если не передан репозиторий или логгер.
+func NewMessageUseCase(deps *MessageDeps) *MessageUseCase {
+	if deps.Repo == nil {
+		panic("message repository cannot be nil")
+	}
+	if deps.Log == nil {
+		panic("Logger cannot be nil")
+	}
+
+	return &MessageUseCase{
+		deps: deps,
+		now:  time.Now,
+	}
+}
+
+// Ingest декодирует сырое сообщение, проверяет его и сохраняет в репозиторий.
+// Ошибки формата и валидации оборачивают domain.ErrInvalidMessage.
+// Повторная доставка уже сохраненного сообщения не считается ошибкой:
+// возвращается ранее сохраненная версия, а счетчик дубликатов увеличивается.
+func (uc *MessageUseCase) Ingest(ctx context.Context, raw []byte, src domain.Source) (*domain.Message, error) {
+	in, err := decode(raw, src)
+	if err != nil {
+		return nil, err
+	}
+	return uc.Store(ctx, in)
+}
+
+// Store проверяет и идемпотентно сохраняет структурированное сообщение.
+// Ошибки валидации оборачивают domain.ErrInvalidMessage.
+func (uc *MessageUseCase) Store(ctx context.Context, in NewMessage) (*domain.Message, error) {
+	const op = "MessageUseCase.Store"
+
+	msg := uc.build(in)
+	src := in.Source
+
+	if err := msg.Validate(); err != nil {
+		return nil, fmt.Errorf("validate message: %w", err)
+	}
+
+	if err := uc.deps.Repo.Save(ctx, msg); err != nil {
+		if errors.Is(err, domain.ErrDuplicateMessage) {
+			return uc.duplicate(ctx, msg), nil
+		}
+		return nil, fmt.Errorf("save message: %w", err)
+	}
+
+	uc.deps.Log.DebugContext(ctx, "message stored",
+		slog.String("op", op),
+		slog.String("id", msg.ID),
+		slog.String("key", msg.Key),
+		slog.String("chat_id", msg.ChatID),
+		slog.String("topic", src.Topic),
+		slog.Int("partition", src.Partition),
+		slog.Int64("offset", src.Offset),
+	)
+
+	return msg, nil
+}
+
+// IngestBatch декодирует, проверяет и сохраняет пачку сообщений одной
+// пакетной записью. Возвращаемый срез выровнен по входу; для неудачных
+// элементов в нем nil, а ошибка имеет тип *domain.BatchError с индексами
+// входного среза. Дубликаты считаются успешно сохраненными: для них
+// возвращается ранее сохраненная версия, как и в Store.
+func (uc *MessageUseCase) IngestBatch(ctx context.Context, batch []RawMessage) ([]*domain.Message, error) {
+	const op = "MessageUseCase.IngestBatch"
+
+	out := make([]*domain.Message, len(batch))
+	failed := make(map[int]error)
+
+	valid := make([]*domain.Message, 0, len(batch))
+	positions := make([]int, 0, len(batch))
+	for i, raw := range batch {
+		in, err := decode(raw.Data, raw.Source)
+		var msg *domain.Message
+		if err == nil {
+			msg = uc.build(in)
+			err = msg.Validate()
+		}
+		if err != nil {
+			failed[i] = fmt.Errorf("validate message: %w", err)
+			continue
+		}
+		valid = append(valid, msg)
+		positions = append(positions, i)
+	}
+
+	err := uc.deps.Repo.SaveMany(ctx, valid)
+
+	var batchErr *domain.BatchError
+	switch {
+	case err == nil:
+	case errors.As(err, &batchErr):
+		for j, itemErr := range batchErr.Errors {
+			if errors.Is(itemErr, domain.ErrDuplicateMessage) {
+				valid[j] = uc.duplicate(ctx, valid[j])
+				continue
+			}
+			failed[positions[j]] = fmt.Errorf("save message: %w", itemErr)
+		}
+	default:
+		for _, i := range positions {
+			failed[i] = fmt.Errorf("save messages: %w", err)
+		}
+	}
+
+	for j, msg := range valid {
+		if _, ok := failed[positions[j]]; !ok {
+			out[positions[j]] = msg
+		}
+	}
+
+	uc.deps.Log.DebugContext(ctx, "message batch stored",
+		slog.String("op", op),
+		slog.Int("size", len(batch)),
+		slog.Int("failed", len(failed)),
+	)
+
+	if len(failed) > 0 {
+		return out, &domain.BatchError{Errors: failed}
+	}
+	return out, nil
+}
+
+// Get возвращает сохраненное сообщение по идентификатору.
+func (uc *MessageUseCase) Get(ctx context.Context, id string) (*domain.Message, error) {
+	msg, err := uc.deps.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	return msg, nil
+}
+
+// List возвращает страницу истории чата. Нулевой лимит заменяется на
+// DefaultPageSize, лимит больше MaxPageSize или отрицательный отклоняется.
+func (uc *MessageUseCase) List(ctx context.Context, q domain.ListQuery) (*MessagePage, error) {
+	switch {
+	case q.ChatID == "":
+		return nil, fmt.Errorf("%w: chat id is required", domain.ErrInvalidQuery)
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0 || q.Limit > MaxPageSize:
+		return nil, fmt.Errorf("%w: limit must be in [1, %d]", domain.ErrInvalidQuery, MaxPageSize)
+	}
+
+	limit := q.Limit
+	q.Limit++ // лишний элемент показывает, есть ли следующая страница
+
+	msgs, err := uc.deps.Repo.ListByChat(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+
+	page := &MessagePage{Messages: msgs}
+	if len(msgs) > limit {
+		page.HasMore = true
+		if q.After != nil && q.Before == nil {
+			// Страница «новее курсора» примыкает к нему снизу: лишний — самый новый.
+			page.Messages = msgs[1:]
+		} else {
+			page.Messages = msgs[:limit]
+		}
+	}
+	return page, nil
+}
+
+// Duplicates возвращает число подавленных повторных сохранений.
+func (uc *MessageUseCase) Duplicates() uint64 { return uc.duplicates.Load() }
+
+// duplicate учитывает подавленный дубликат и возвращает ранее сохраненное
+// сообщение: по ключу, а у сообщения без ключа — по идентификатору. Если его
+// не удалось прочитать, возвращается входное сообщение.
+func (uc *MessageUseCase) duplicate(ctx context.Context, msg *domain.Message) *domain.Message {
+	const op = "MessageUseCase.duplicate"
+	log := uc.deps.Log.With(slog.String("op", op), slog.String("key", msg.Key))
+
+	total := uc.duplicates.Add(1)
+	log.DebugContext(ctx, "duplicate message suppressed", slog.Uint64("duplicates_total", total))
+
+	var (
+		stored *domain.Message
+		err    error
+	)
+	if msg.Key != "" {
+		stored, err = uc.deps.Repo.GetByKey(ctx, msg.Key)
+	} else {
+		stored, err = uc.deps.Repo.GetByID(ctx, msg.ID)
+	}
+	if err != nil {
+		log.WarnContext(ctx, "failed to load stored duplicate", slog.String("error", err.Error()))
+		return msg
+	}
+	return stored
+}
+
+// decode разбирает JSON-представление сообщения отправителя.
+func decode(raw []byte, src domain.Source) (NewMessage, error) {
+	var in incomingMessage
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return NewMessage{}, fmt.Errorf("%w: decode payload: %w", domain.ErrInvalidMessage, err)
+	}
+
+	return NewMessage{
+		ID:          in.ID,
+		ChatID:      in.ChatID,
+		SenderID:    in.SenderID,
+		ContentType: in.ContentType,
+		Payload:     []byte(in.Payload),
+		CreatedAt:   in.CreatedAt,
+		Source:      src,
+	}, nil
+}
+
+// build создает доменное сообщение, подставляя значения по умолчанию.
+func (uc *MessageUseCase) build(in NewMessage) *domain.Message {
+	receivedAt := uc.now().UTC()
+
+	msg := &domain.Message{
+		Key:         domain.MessageKey(in.ID, in.Source),
+		ChatID:      in.ChatID,
+		SenderID:    in.SenderID,
+		Payload:     in.Payload,
+		ContentType: in.ContentType,
+		CreatedAt:   in.CreatedAt,
+		ReceivedAt:  receivedAt,
+		Source:      in.Source,
+	}
+	if msg.ContentType == "" {
+		msg.ContentType = defaultContentType
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = receivedAt
+	}
+
+	return msg
+}
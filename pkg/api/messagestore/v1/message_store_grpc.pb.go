@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: messagestore/v1/message_store.proto
+
+// Пакет messagestore.v1 описывает gRPC-API сервиса хранения сообщений.
+
+package messagestorev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MessageStore_Store_FullMethodName = "/messagestore.v1.MessageStore/Store"
+	MessageStore_Get_FullMethodName   = "/messagestore.v1.MessageStore/Get"
+	MessageStore_List_FullMethodName  = "/messagestore.v1.MessageStore/List"
+)
+
+// MessageStoreClient is the client API for MessageStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MessageStore сохраняет сообщения чатов и отдает их историю.
+type MessageStoreClient interface {
+	// Store сохраняет сообщение. Повторный вызов с тем же id возвращает
+	// ранее сохраненное сообщение.
+	Store(ctx context.Context, in *StoreRequest, opts ...grpc.CallOption) (*StoreResponse, error)
+	// Get возвращает сообщение по идентификатору.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// List передает сообщения чата от новых к старым (или от курсора after
+	// к более новым) потоком, пока не будет исчерпан limit или история.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error)
+}
+
+type messageStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessageStoreClient(cc grpc.ClientConnInterface) MessageStoreClient {
+	return &messageStoreClient{cc}
+}
+
+func (c *messageStoreClient) Store(ctx context.Context, in *StoreRequest, opts ...grpc.CallOption) (*StoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StoreResponse)
+	err := c.cc.Invoke(ctx, MessageStore_Store_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageStoreClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, MessageStore_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageStoreClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessageStore_ServiceDesc.Streams[0], MessageStore_List_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, ListResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageStore_ListClient = grpc.ServerStreamingClient[ListResponse]
+
+// MessageStoreServer is the server API for MessageStore service.
+// All implementations must embed UnimplementedMessageStoreServer
+// for forward compatibility.
+//
+// MessageStore сохраняет сообщения чатов и отдает их историю.
+type MessageStoreServer interface {
+	// Store сохраняет сообщение. Повторный вызов с тем же id возвращает
+	// ранее сохраненное сообщение.
+	Store(context.Context, *StoreRequest) (*StoreResponse, error)
+	// Get возвращает сообщение по идентификатору.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// List передает сообщения чата от новых к старым (или от курсора after
+	// к более новым) потоком, пока не будет исчерпан limit или история.
+	List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error
+	mustEmbedUnimplementedMessageStoreServer()
+}
+
+// UnimplementedMessageStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMessageStoreServer struct{}
+
+func (UnimplementedMessageStoreServer) Store(context.Context, *StoreRequest) (*StoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Store not implemented")
+}
+func (UnimplementedMessageStoreServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedMessageStoreServer) List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedMessageStoreServer) mustEmbedUnimplementedMessageStoreServer() {}
+func (UnimplementedMessageStoreServer) testEmbeddedByValue()                      {}
+
+// UnsafeMessageStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessageStoreServer will
+// result in compilation errors.
+type UnsafeMessageStoreServer interface {
+	mustEmbedUnimplementedMessageStoreServer()
+}
+
+func RegisterMessageStoreServer(s grpc.ServiceRegistrar, srv MessageStoreServer) {
+	// If the following call pancis, it indicates UnimplementedMessageStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MessageStore_ServiceDesc, srv)
+}
+
+func _MessageStore_Store_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageStoreServer).Store(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageStore_Store_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageStoreServer).Store(ctx, req.(*StoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageStoreServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageStore_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageStoreServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageStore_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MessageStoreServer).List(m, &grpc.GenericServerStream[ListRequest, ListResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageStore_ListServer = grpc.ServerStreamingServer[ListResponse]
+
+// MessageStore_ServiceDesc is the grpc.ServiceDesc for MessageStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MessageStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messagestore.v1.MessageStore",
+	HandlerType: (*MessageStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Store",
+			Handler:    _MessageStore_Store_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _MessageStore_Get_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _MessageStore_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "messagestore/v1/message_store.proto",
+}
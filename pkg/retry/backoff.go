@@ -9,15 +9,13 @@ package retry
 import (
 	"context"
 	"time"
-
-	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
 )
 
 // Backoff представляет собой структуру, управляющую задержками между повторными
-// попытками выполнения операции. Использует параметры из RetryConfig.
+// попытками выполнения операции. Использует параметры Policy.
 type Backoff struct {
-	cfg   *config.Config
-	delay time.Duration
+	policy Policy
+	delay  time.Duration
 }
 
 // NewBackoff создаёт новый экземпляр Backoff с начальными параметрами.
@@ -25,12 +23,12 @@ type Backoff struct {
 //
 // Пример:
 //
-//	b := retry.NewBackoff(&config.RetryConfig{Attempts: 5, Initial: 500 * time.Millisecond})
-func NewBackoff(cfg *config.Config) *Backoff {
-	sanitize(cfg)
+//	b := retry.NewBackoff(retry.Policy{Initial: 500 * time.Millisecond, Max: 10 * time.Second})
+func NewBackoff(policy Policy) *Backoff {
+	policy = policy.withDefaults()
 	return &Backoff{
-		cfg:   cfg,
-		delay: cfg.Initial,
+		policy: policy,
+		delay:  policy.Initial,
 	}
 }
 
@@ -38,7 +36,7 @@ func NewBackoff(cfg *config.Config) *Backoff {
 // Метод увеличивает текущую задержку экспоненциально и учитывает джиттер (если включён).
 // Поддерживает отмену по контексту.
 func (b *Backoff) Sleep(ctx context.Context) {
-	b.delay = nextDelay(b.delay, b.cfg)
+	b.delay = nextDelay(b.delay, b.policy)
 
 	timer := time.NewTimer(b.delay)
 	select {
@@ -51,7 +49,7 @@ func (b *Backoff) Sleep(ctx context.Context) {
 }
 
 // Reset сбрасывает накопленный интервал задержки к начальному значению,
-// определённому в конфигурации.
+// определённому в политике.
 func (b *Backoff) Reset() {
-	b.delay = b.cfg.Initial
+	b.delay = b.policy.Initial
 }
@@ -0,0 +1,317 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// State — состояние предохранителя.
+type State int
+
+const (
+	// StateClosed — вызовы проходят, результаты учитываются в окне.
+	StateClosed State = iota
+	// StateOpen — вызовы отклоняются с ErrBreakerOpen до истечения Cooldown.
+	StateOpen
+	// StateHalfOpen — пропускается ограниченное число пробных вызовов.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerPolicy задает параметры предохранителя. Нулевые поля заменяются
+// значениями DefaultBreakerPolicy.
+type BreakerPolicy struct {
+	// Window — длительность скользящего окна, в котором считается доля отказов.
+	Window time.Duration
+	// MinRequests — минимум вызовов в окне, после которого доля отказов
+	// может разомкнуть предохранитель.
+	MinRequests int
+	// FailureRatio — доля отказов в окне (0..1], при которой предохранитель размыкается.
+	FailureRatio float64
+	// Cooldown — время в разомкнутом состоянии до пробных вызовов.
+	Cooldown time.Duration
+	// HalfOpenRequests — число пробных вызовов; если все успешны,
+	// предохранитель замыкается.
+	HalfOpenRequests int
+}
+
+// DefaultBreakerPolicy содержит значения, которые подставляются вместо нулевых полей BreakerPolicy.
+var DefaultBreakerPolicy = BreakerPolicy{
+	Window:           10 * time.Second,
+	MinRequests:      10,
+	FailureRatio:     0.5,
+	Cooldown:         5 * time.Second,
+	HalfOpenRequests: 1,
+}
+
+func (p BreakerPolicy) withDefaults() BreakerPolicy {
+	if p.Window <= 0 {
+		p.Window = DefaultBreakerPolicy.Window
+	}
+	if p.MinRequests <= 0 {
+		p.MinRequests = DefaultBreakerPolicy.MinRequests
+	}
+	if p.FailureRatio <= 0 || p.FailureRatio > 1 {
+		p.FailureRatio = DefaultBreakerPolicy.FailureRatio
+	}
+	if p.Cooldown <= 0 {
+		p.Cooldown = DefaultBreakerPolicy.Cooldown
+	}
+	if p.HalfOpenRequests <= 0 {
+		p.HalfOpenRequests = DefaultBreakerPolicy.HalfOpenRequests
+	}
+	return p
+}
+
+// windowBuckets — число корзин скользящего окна.
+const windowBuckets = 10
+
+// bucket хранит результаты вызовов за один интервал окна.
+type bucket struct {
+	slot      int64
+	successes int
+	failures  int
+}
+
+// BreakerOption настраивает Breaker.
+type BreakerOption func(*Breaker)
+
+// WithFailureClassifier задает, какие ошибки считаются отказами. Остальные
+// ошибки (например, нарушение уникальности) учитываются как успешные вызовы.
+// По умолчанию отказом считается любая непостоянная ошибка, кроме отмены контекста.
+func WithFailureClassifier(isFailure Classifier) BreakerOption {
+	return func(b *Breaker) {
+		if isFailure != nil {
+			b.isFailure = isFailure
+		}
+	}
+}
+
+// WithStateChange задает обработчик смены состояния. Он вызывается под
+// блокировкой предохранителя и не должен вызывать его методы.
+func WithStateChange(fn func(name string, from, to State)) BreakerOption {
+	return func(b *Breaker) { b.onChange = fn }
+}
+
+// Breaker — предохранитель: размыкается, когда доля отказов в скользящем
+// окне достигает FailureRatio, отклоняет вызовы в течение Cooldown, затем
+// пропускает пробные вызовы и по их результату замыкается или снова размыкается.
+type Breaker struct {
+	name      string
+	policy    BreakerPolicy
+	isFailure Classifier
+	onChange  func(name string, from, to State)
+	now       func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	buckets    [windowBuckets]bucket
+	openedAt   time.Time
+	probes     int
+	passed     int
+	// changed закрывается и заменяется при каждой смене состояния (см. Wait).
+	changed chan struct{}
+}
+
+// NewBreaker создает замкнутый предохранитель с именем name.
+func NewBreaker(name string, policy BreakerPolicy, opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		name:      name,
+		policy:    policy.withDefaults(),
+		isFailure: defaultFailure,
+		now:       time.Now,
+		changed:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func defaultFailure(err error) bool {
+	return Retryable(err, nil) && !errors.Is(err, context.Canceled)
+}
+
+// Name возвращает имя предохранителя.
+func (b *Breaker) Name() string { return b.name }
+
+// State возвращает текущее состояние предохранителя.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Execute вызывает fn, если предохранитель пропускает вызов, и учитывает
+// результат. Иначе сразу возвращает ErrBreakerOpen.
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	b.record(generation, err)
+	return err
+}
+
+// Wait блокируется, пока предохранитель не пропустит вызов: разомкнутый —
+// до конца Cooldown, полуразомкнутый — пока заняты все пробные вызовы.
+// Возвращает ошибку контекста, если ожидание прервано.
+func (b *Breaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refresh()
+		var wait time.Duration
+		switch {
+		case b.state == StateClosed:
+			b.mu.Unlock()
+			return nil
+		case b.state == StateHalfOpen && b.probes < b.policy.HalfOpenRequests:
+			b.mu.Unlock()
+			return nil
+		case b.state == StateOpen:
+			wait = b.openedAt.Add(b.policy.Cooldown).Sub(b.now())
+		default:
+			// Пробные вызовы заняты: ждем их результата, но не дольше Cooldown.
+			wait = b.policy.Cooldown
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case StateOpen:
+		return 0, ErrBreakerOpen
+	case StateHalfOpen:
+		if b.probes >= b.policy.HalfOpenRequests {
+			return 0, ErrBreakerOpen
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Вызов начался до смены состояния — его результат уже не показателен.
+	if generation != b.generation {
+		return
+	}
+
+	failed := err != nil && b.isFailure(err)
+	ignored := err != nil && !failed && errors.Is(err, context.Canceled)
+
+	switch b.state {
+	case StateClosed:
+		if ignored {
+			return
+		}
+		bk := b.bucket()
+		if failed {
+			bk.failures++
+		} else {
+			bk.successes++
+		}
+		if b.tripped() {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.probes--
+		switch {
+		case failed:
+			b.setState(StateOpen)
+		case ignored:
+		default:
+			b.passed++
+			if b.passed >= b.policy.HalfOpenRequests {
+				b.setState(StateClosed)
+			}
+		}
+	case StateOpen:
+	}
+}
+
+// refresh переводит разомкнутый предохранитель в полуразомкнутый по истечении Cooldown.
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.policy.Cooldown)) {
+		b.setState(StateHalfOpen)
+	}
+}
+
+func (b *Breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes, b.passed = 0, 0
+	b.buckets = [windowBuckets]bucket{}
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+
+	if b.onChange != nil {
+		b.onChange(b.name, from, to)
+	}
+}
+
+// bucket возвращает корзину текущего интервала окна, сбрасывая устаревшую.
+func (b *Breaker) bucket() *bucket {
+	width := max(int64(b.policy.Window/windowBuckets), 1)
+	slot := b.now().UnixNano() / width
+	bk := &b.buckets[slot%windowBuckets]
+	if bk.slot != slot {
+		*bk = bucket{slot: slot}
+	}
+	return bk
+}
+
+// tripped сообщает, достигла ли доля отказов в окне порога.
+func (b *Breaker) tripped() bool {
+	current := b.bucket().slot
+	var successes, failures int
+	for _, bk := range b.buckets {
+		if current-bk.slot < windowBuckets {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+	total := successes + failures
+	return total >= b.policy.MinRequests && float64(failures)/float64(total) >= b.policy.FailureRatio
+}
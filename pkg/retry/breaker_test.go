@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("temporary")
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	var changes []string
+	b := NewBreaker("test", BreakerPolicy{
+		Window:           time.Second,
+		MinRequests:      4,
+		FailureRatio:     0.5,
+		Cooldown:         time.Second,
+		HalfOpenRequests: 2,
+	}, WithStateChange(func(_ string, from, to State) {
+		changes = append(changes, from.String()+"->"+to.String())
+	}))
+	b.now = func() time.Time { return now }
+
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errTemporary }
+	ctx := context.Background()
+
+	// Ошибки, которые не считаются отказами, предохранитель не размыкают.
+	for range 4 {
+		_ = b.Execute(ctx, func(context.Context) error { return Permanent(errTemporary) })
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state after permanent errors = %s, want closed", got)
+	}
+
+	// 2 отказа из 4 вызовов в окне — порог 0.5 достигнут.
+	now = now.Add(2 * time.Second)
+	_ = b.Execute(ctx, ok)
+	_ = b.Execute(ctx, fail)
+	_ = b.Execute(ctx, ok)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state before MinRequests = %s, want closed", got)
+	}
+	_ = b.Execute(ctx, fail)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("state after failures = %s, want open", got)
+	}
+	if err := b.Execute(ctx, ok); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("open breaker: err = %v, want ErrBreakerOpen", err)
+	}
+
+	// После Cooldown — пробные вызовы; отказ пробы снова размыкает.
+	now = now.Add(time.Second)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("state after cooldown = %s, want half-open", got)
+	}
+	if err := b.Execute(ctx, fail); !errors.Is(err, errTemporary) {
+		t.Fatalf("probe: err = %v", err)
+	}
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("state after failed probe = %s, want open", got)
+	}
+
+	// Успех всех пробных вызовов замыкает предохранитель; лишние вызовы,
+	// пока пробы заняты, отклоняются.
+	now = now.Add(time.Second)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error)
+	for range 2 {
+		go func() {
+			done <- b.Execute(ctx, func(context.Context) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+		<-started
+	}
+	if err := b.Execute(ctx, ok); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("extra probe: err = %v, want ErrBreakerOpen", err)
+	}
+	close(release)
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state after probes = %s, want closed", got)
+	}
+
+	want := []string{
+		"closed->open", "open->half-open", "half-open->open",
+		"open->half-open", "half-open->closed",
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %q, want %q", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %q, want %q", changes, want)
+		}
+	}
+}
+
+func TestBreakerWindowExpires(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("test", BreakerPolicy{Window: time.Second, MinRequests: 2, FailureRatio: 0.5})
+	b.now = func() time.Time { return now }
+
+	fail := func(context.Context) error { return errTemporary }
+	_ = b.Execute(context.Background(), fail)
+
+	// Отказ из прошлого окна не учитывается.
+	now = now.Add(2 * time.Second)
+	_ = b.Execute(context.Background(), fail)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state = %s, want closed", got)
+	}
+}
+
+func TestBreakerWait(t *testing.T) {
+	b := NewBreaker("test", BreakerPolicy{MinRequests: 1, Cooldown: 20 * time.Millisecond})
+	_ = b.Execute(context.Background(), func(context.Context) error { return errTemporary })
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("state = %s, want open", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait with canceled ctx = %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("state after Wait = %s, want half-open", got)
+	}
+}
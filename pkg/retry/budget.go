@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// BudgetPolicy задает бюджет повторов — ведро токенов, общее для всех
+// вызывающих одной политики. Каждый вызов Do добавляет в ведро Ratio токена,
+// каждый повтор забирает один: при Ratio 0.1 повторов не больше 10% вызовов.
+// Нулевой Ratio отключает бюджет.
+type BudgetPolicy struct {
+	// Ratio — допустимая доля повторов от числа вызовов.
+	Ratio float64
+	// MinPerSecond — число повторов в секунду, доступное независимо от
+	// числа вызовов (например, при редких запросах).
+	MinPerSecond float64
+	// Burst — емкость ведра; изначально оно заполнено.
+	Burst float64
+}
+
+// Enabled сообщает, ограничивает ли политика повторы.
+func (p BudgetPolicy) Enabled() bool { return p.Ratio > 0 }
+
+// defaultBudgetBurst — емкость ведра, если Burst не задан.
+const defaultBudgetBurst = 10
+
+const tokenEpsilon = 1e-9
+
+// Budget ограничивает долю повторов среди вызовов. Методы безопасно
+// вызывать конкурентно и на nil-получателе: nil-бюджет ничего не ограничивает.
+type Budget struct {
+	policy BudgetPolicy
+	burst  float64
+	now    func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBudget создает бюджет с заполненным ведром.
+func NewBudget(policy BudgetPolicy) *Budget {
+	burst := policy.Burst
+	if burst <= 0 {
+		burst = defaultBudgetBurst
+	}
+	b := &Budget{
+		policy: policy,
+		burst:  burst,
+		now:    time.Now,
+		tokens: burst,
+	}
+	b.last = b.now()
+	return b
+}
+
+// Deposit учитывает новый вызов.
+func (b *Budget) Deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = min(b.burst, b.tokens+b.policy.Ratio)
+}
+
+// Withdraw забирает токен на повтор. Возвращает false, если бюджет исчерпан.
+func (b *Budget) Withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	// Допуск компенсирует накопленную ошибку округления дробных Ratio.
+	if b.tokens < 1-tokenEpsilon {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refill начисляет токены MinPerSecond за прошедшее время.
+func (b *Budget) refill() {
+	now := b.now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.policy.MinPerSecond)
+	}
+	b.last = now
+}
@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBudget(BudgetPolicy{Ratio: 0.5, MinPerSecond: 1, Burst: 2})
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	// Ведро изначально заполнено.
+	for i := range 2 {
+		if !b.Withdraw() {
+			t.Fatalf("withdraw %d from full budget failed", i)
+		}
+	}
+	if b.Withdraw() {
+		t.Fatal("withdraw from exhausted budget succeeded")
+	}
+
+	// Два вызова по 0.5 токена оплачивают один повтор.
+	b.Deposit()
+	if b.Withdraw() {
+		t.Fatal("withdraw after half a token succeeded")
+	}
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Fatal("withdraw after deposits failed")
+	}
+
+	// MinPerSecond пополняет ведро со временем, но не сверх Burst.
+	now = now.Add(time.Second)
+	if !b.Withdraw() || b.Withdraw() {
+		t.Fatal("refill after 1s should allow exactly one retry")
+	}
+	now = now.Add(time.Minute)
+	for i := range 2 {
+		if !b.Withdraw() {
+			t.Fatalf("withdraw %d after refill failed", i)
+		}
+	}
+	if b.Withdraw() {
+		t.Fatal("refill exceeded burst")
+	}
+}
+
+func TestBudgetFractionalRatio(t *testing.T) {
+	b := NewBudget(BudgetPolicy{Ratio: 0.1, Burst: 1})
+	b.now = func() time.Time { return b.last }
+	if !b.Withdraw() {
+		t.Fatal("withdraw from full budget failed")
+	}
+
+	// Десять вызовов по 0.1 токена дают ровно один повтор, несмотря на округление.
+	for range 10 {
+		b.Deposit()
+	}
+	if !b.Withdraw() {
+		t.Fatal("withdraw after 10 deposits of 0.1 failed")
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Fatal("nil budget limited retries")
+	}
+}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"errors"
+	"time"
+)
+
+// Classifier сообщает, имеет ли смысл повторять операцию после ошибки err.
+type Classifier func(err error) bool
+
+// permanentError помечает ошибку, повтор после которой бесполезен.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как постоянную: Do прекращает попытки и
+// возвращает err без обертки. Для nil возвращает nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent сообщает, помечена ли ошибка через Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Retryable сообщает, стоит ли повторять операцию после ошибки err:
+// постоянные ошибки и ErrBreakerOpen не повторяются, остальные проверяются
+// классификатором. Без классификатора повторяется любая другая ошибка.
+func Retryable(err error, classify Classifier) bool {
+	if err == nil || IsPermanent(err) || errors.Is(err, ErrBreakerOpen) {
+		return false
+	}
+	return classify == nil || classify(err)
+}
+
+// unwrapPermanent снимает пометку Permanent с верхнего уровня ошибки.
+func unwrapPermanent(err error) error {
+	if p, ok := err.(*permanentError); ok { //nolint:errorlint // снимаем только внешнюю обертку
+		return p.err
+	}
+	return err
+}
+
+// WithClassifier задает классификатор ошибок: ошибка, которую он не считает
+// временной, завершает Do без повторов.
+func WithClassifier(classify Classifier) Option {
+	return func(o *options) { o.classify = classify }
+}
+
+// OnRetry добавляет обработчик, который Do вызывает перед каждой паузой с
+// номером неудачной попытки, длительностью паузы и ошибкой.
+func OnRetry(fn func(attempt int, delay time.Duration, err error)) Option {
+	if fn == nil {
+		return func(*options) {}
+	}
+	return WithObserver(func(a Attempt) { fn(a.Number, a.Delay, a.Err) })
+}
@@ -0,0 +1,12 @@
+package retry
+
+import "errors"
+
+var (
+	// ErrBreakerOpen возвращается Breaker.Execute, пока предохранитель разомкнут.
+	// Do не повторяет операцию после этой ошибки.
+	ErrBreakerOpen = errors.New("retry: circuit breaker is open")
+	// ErrBudgetExhausted оборачивает последнюю ошибку Do, если повтор не
+	// состоялся из-за исчерпанного бюджета (см. Budget).
+	ErrBudgetExhausted = errors.New("retry: retry budget exhausted")
+)
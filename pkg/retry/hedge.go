@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// HedgePolicy задает страхующие попытки: если ответа нет дольше Delay,
+// параллельно запускается следующая попытка, всего не больше Attempts.
+// Нулевой Delay или Attempts не больше 1 отключают страховку.
+type HedgePolicy struct {
+	Delay    time.Duration
+	Attempts int
+}
+
+// Enabled сообщает, запускает ли политика страхующие попытки.
+func (p HedgePolicy) Enabled() bool { return p.Delay > 0 && p.Attempts > 1 }
+
+// Hedge выполняет идемпотентную операцию fn со страхующими попытками по
+// policy.Hedge и возвращает первый успешный результат, отменяя остальные
+// попытки. Временная ошибка попытки запускает следующую сразу, постоянная
+// (Permanent или отвергнутая классификатором) возвращается сразу. Если все
+// попытки неудачны, возвращается последняя ошибка. Бюджет из WithBudget
+// ограничивает и страхующие попытки.
+func Hedge[T any](
+	ctx context.Context,
+	policy Policy,
+	fn func(ctx context.Context) (T, error),
+	opts ...Option,
+) (T, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	hp := policy.Hedge
+	if !hp.Enabled() {
+		v, err := fn(ctx)
+		return v, unwrapPermanent(err)
+	}
+	o.budget.Deposit()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result, hp.Attempts)
+	launch := func() {
+		go func() {
+			v, err := fn(ctx)
+			results <- result{value: v, err: err}
+		}()
+	}
+
+	launch()
+	launched, pending := 1, 1
+	timer := time.NewTimer(hp.Delay)
+	defer timer.Stop()
+
+	var (
+		zero    T
+		lastErr error
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.value, nil
+			}
+			lastErr = unwrapPermanent(r.err)
+			if !Retryable(r.err, o.classify) {
+				return zero, lastErr
+			}
+			if launched < hp.Attempts && o.budget.Withdraw() {
+				launch()
+				launched++
+				pending++
+			}
+			if pending == 0 {
+				return zero, lastErr
+			}
+		case <-timer.C:
+			if launched < hp.Attempts && o.budget.Withdraw() {
+				launch()
+				launched++
+				pending++
+				timer.Reset(hp.Delay)
+			}
+		}
+	}
+}
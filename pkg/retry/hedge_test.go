@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgeWinnerCancelsLosers(t *testing.T) {
+	policy := Policy{Hedge: HedgePolicy{Delay: 10 * time.Millisecond, Attempts: 3}}
+
+	var calls atomic.Int32
+	canceled := make(chan struct{}, 3)
+	v, err := Hedge(context.Background(), policy, func(ctx context.Context) (int, error) {
+		n := calls.Add(1)
+		if n == 2 {
+			// Вторая попытка отвечает первой.
+			return int(n), nil
+		}
+		<-ctx.Done()
+		canceled <- struct{}{}
+		return 0, ctx.Err()
+	})
+	if err != nil || v != 2 {
+		t.Fatalf("Hedge = %d, %v; want 2, nil", v, err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("losing attempt was not canceled")
+	}
+}
+
+func TestHedge(t *testing.T) {
+	tests := []struct {
+		name string
+		// errs — ошибки попыток по порядку; попытки сверх списка успешны.
+		errs      []error
+		budget    *Budget
+		wantErr   error
+		wantCalls int32
+	}{
+		{
+			name:      "temporary error launches next attempt immediately",
+			errs:      []error{errTemporary},
+			wantCalls: 2,
+		},
+		{
+			name:      "permanent error stops",
+			errs:      []error{Permanent(errTemporary)},
+			wantErr:   errTemporary,
+			wantCalls: 1,
+		},
+		{
+			name:      "all attempts fail",
+			errs:      []error{errTemporary, errTemporary, errTemporary},
+			wantErr:   errTemporary,
+			wantCalls: 3,
+		},
+		{
+			name:      "budget limits hedged attempts",
+			errs:      []error{errTemporary, errTemporary},
+			budget:    NewBudget(BudgetPolicy{Ratio: 0.1, Burst: 1}),
+			wantErr:   errTemporary,
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Большая задержка: новые попытки запускаются только после ошибок.
+			policy := Policy{Hedge: HedgePolicy{Delay: time.Hour, Attempts: 3}}
+
+			var calls atomic.Int32
+			_, err := Hedge(context.Background(), policy, func(context.Context) (struct{}, error) {
+				n := int(calls.Add(1))
+				if n <= len(tt.errs) {
+					return struct{}{}, tt.errs[n-1]
+				}
+				return struct{}{}, nil
+			}, WithBudget(tt.budget))
+
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if IsPermanent(err) {
+				t.Fatal("Permanent mark leaked to the caller")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("attempts = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package kafkaretry классифицирует ошибки kafka-go для retry.WithClassifier.
+package kafkaretry
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Classify сообщает, временная ли ошибка kafka-go. Коды протокола Kafka
+// повторяются, только если брокер считает их временными (kafka.Error.Temporary),
+// закрытый ридер или писатель и отмена контекста — никогда. Прочие ошибки
+// (обрывы соединения, таймауты сети) считаются временными.
+func Classify(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, io.ErrClosedPipe) {
+		return false
+	}
+
+	var kerr kafka.Error
+	if errors.As(err, &kerr) {
+		return kerr.Temporary()
+	}
+	return true
+}
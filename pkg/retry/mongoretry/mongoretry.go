@@ -0,0 +1,32 @@
+// Package mongoretry классифицирует ошибки драйвера MongoDB для retry.WithClassifier.
+package mongoretry
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Метки ошибок сервера, после которых операцию можно повторить.
+const (
+	labelTransientTransaction = "TransientTransactionError"
+	labelRetryableWrite       = "RetryableWriteError"
+)
+
+// Classify сообщает, временная ли ошибка MongoDB: сетевые сбои, таймауты и
+// ошибки с метками TransientTransactionError или RetryableWriteError.
+// Нарушение уникального индекса, ошибки валидации и прочие ответы сервера
+// считаются постоянными, как и отмена контекста.
+func Classify(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), mongo.IsDuplicateKeyError(err):
+		return false
+	case mongo.IsNetworkError(err), mongo.IsTimeout(err):
+		return true
+	}
+
+	var le mongo.LabeledError
+	return errors.As(err, &le) &&
+		(le.HasErrorLabel(labelTransientTransaction) || le.HasErrorLabel(labelRetryableWrite))
+}
@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// Policy задает параметры повторов одной операции. Нулевые поля заменяются
+// значениями DefaultPolicy.
+type Policy struct {
+	// Attempts — общее число попыток, включая первую.
+	Attempts int
+	// Initial — пауза перед первым повтором.
+	Initial time.Duration
+	// Max ограничивает паузу сверху.
+	Max time.Duration
+	// Factor — множитель паузы после каждой неудачи.
+	Factor float64
+	// Jitter добавляет к паузе случайную прибавку до половины ее длины.
+	Jitter bool
+	// Budget — общий для всех вызывающих бюджет повторов (см. Registry.Budget).
+	Budget BudgetPolicy
+	// Hedge — страхующие попытки для идемпотентных операций (см. Hedge).
+	Hedge HedgePolicy
+}
+
+// DefaultPolicy содержит значения, которые подставляются вместо нулевых полей Policy.
+var DefaultPolicy = Policy{
+	Attempts: 3,
+	Initial:  time.Second,
+	Max:      30 * time.Second,
+	Factor:   2.0,
+}
+
+// withDefaults возвращает копию политики с безопасными значениями по умолчанию.
+func (p Policy) withDefaults() Policy {
+	if p.Attempts <= 0 {
+		p.Attempts = DefaultPolicy.Attempts
+	}
+	if p.Initial <= 0 {
+		p.Initial = DefaultPolicy.Initial
+	}
+	if p.Max <= 0 {
+		p.Max = DefaultPolicy.Max
+	}
+	if p.Factor < 1 {
+		p.Factor = DefaultPolicy.Factor
+	}
+	return p
+}
+
+// Registry хранит именованные политики повторов и их бюджеты. Для
+// неизвестного имени возвращается политика по умолчанию. Политики можно
+// заменять на лету: операции, начатые раньше, дорабатывают со старыми значениями.
+type Registry struct {
+	mu       sync.RWMutex
+	fallback Policy
+	named    map[string]Policy
+	// budgets хранит бюджеты по имени политики; fallbackName — бюджет
+	// политики по умолчанию, общий для всех неизвестных имен.
+	budgets map[string]*Budget
+}
+
+// fallbackName — ключ бюджета политики по умолчанию в Registry.budgets.
+const fallbackName = ""
+
+// NewRegistry создает реестр с политикой по умолчанию fallback и именованными
+// политиками named.
+func NewRegistry(fallback Policy, named map[string]Policy) *Registry {
+	r := &Registry{}
+	r.Update(fallback, named)
+	return r
+}
+
+// Policy возвращает политику с именем name или политику по умолчанию.
+func (r *Registry) Policy(name string) Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.named[name]; ok {
+		return p
+	}
+	return r.fallback
+}
+
+// Budget возвращает бюджет повторов политики name или nil, если бюджет для
+// нее не задан. Бюджет общий для всех вызывающих с этим именем.
+func (r *Registry) Budget(name string) *Budget {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.named[name]; ok {
+		return r.budgets[name]
+	}
+	return r.budgets[fallbackName]
+}
+
+// Update целиком заменяет набор политик. Бюджеты политик, параметры бюджета
+// которых не изменились, сохраняют накопленные токены.
+func (r *Registry) Update(fallback Policy, named map[string]Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budgets := make(map[string]*Budget, len(named)+1)
+	keep := func(name string, p BudgetPolicy) {
+		if !p.Enabled() {
+			return
+		}
+		if b, ok := r.budgets[name]; ok && b.policy == p {
+			budgets[name] = b
+			return
+		}
+		budgets[name] = NewBudget(p)
+	}
+	keep(fallbackName, fallback.Budget)
+	for name, p := range named {
+		keep(name, p.Budget)
+	}
+
+	r.fallback = fallback
+	r.named = maps.Clone(named)
+	r.budgets = budgets
+}
@@ -5,40 +5,98 @@ package retry
 import (
 	"context"
 	"crypto/rand"
-	"errors"
+	"fmt"
 	"math/big"
 	"time"
-
-	"github.com/devoraq/AVQ_message_store/internal/infrastructure/config"
 )
 
+// Attempt описывает неудачную попытку, за которой последует повтор.
+type Attempt struct {
+	// Operation — имя операции, заданное через WithName.
+	Operation string
+	// Number — номер неудачной попытки, начиная с 1.
+	Number int
+	// Delay — пауза перед следующей попыткой.
+	Delay time.Duration
+	// Err — ошибка, которой завершилась попытка.
+	Err error
+}
+
+// Option настраивает отдельный вызов Do.
+type Option func(*options)
+
+type options struct {
+	name      string
+	classify  Classifier
+	budget    *Budget
+	observers []func(Attempt)
+}
+
+// WithName задает имя операции, передаваемое наблюдателям.
+func WithName(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// WithBudget ограничивает повторы общим бюджетом (см. Registry.Budget).
+// nil-бюджет ничего не ограничивает.
+func WithBudget(b *Budget) Option {
+	return func(o *options) { o.budget = b }
+}
+
+// WithObserver добавляет наблюдателя, которого Do вызывает после каждой
+// неудачной попытки перед паузой (например, для метрик).
+func WithObserver(fn func(Attempt)) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.observers = append(o.observers, fn)
+		}
+	}
+}
+
 // Do выполняет переданную функцию fn с логикой повторных попыток.
 // Функция будет повторяться до успешного выполнения или пока не исчерпаются все попытки.
 // Поддерживает отмену через context, экспоненциальный рост задержки и случайный разброс (jitter).
-func Do(ctx context.Context, cfg *config.Config, fn func(ctx context.Context) error) (lastErr error) {
-	if cfg == nil {
-		return errors.New("retry.Do: config cannot be nil")
+// Ошибка, помеченная Permanent или отвергнутая классификатором (WithClassifier),
+// возвращается сразу. Если исчерпан бюджет (WithBudget), последняя ошибка
+// возвращается обернутой в ErrBudgetExhausted.
+func Do(
+	ctx context.Context,
+	policy Policy,
+	fn func(ctx context.Context) error,
+	opts ...Option,
+) (lastErr error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
 	// sane defaults, если значения не заданы
-	sanitize(cfg)
+	policy = policy.withDefaults()
 
-	delay := cfg.Initial
+	delay := policy.Initial
+	o.budget.Deposit()
 
-	for attempt := 1; attempt <= cfg.Attempts; attempt++ {
+	for attempt := 1; attempt <= policy.Attempts; attempt++ {
 		err := fn(ctx)
 		if err == nil {
 			return nil
 		}
-		lastErr = err
+		lastErr = unwrapPermanent(err)
 
-		// если это была последняя попытка — выходим
-		if attempt == cfg.Attempts {
+		// если это была последняя попытка или повтор не поможет — выходим
+		if attempt == policy.Attempts || !Retryable(err, o.classify) {
 			break
 		}
+		if !o.budget.Withdraw() {
+			return fmt.Errorf("%w: %w", ErrBudgetExhausted, lastErr)
+		}
 
 		// считаем следующую задержку
-		delay = nextDelay(delay, cfg)
+		delay = nextDelay(delay, policy)
+
+		for _, observe := range o.observers {
+			observe(Attempt{Operation: o.name, Number: attempt, Delay: delay, Err: err})
+		}
 
 		timer := time.NewTimer(delay)
 		select {
@@ -55,13 +113,13 @@ func Do(ctx context.Context, cfg *config.Config, fn func(ctx context.Context) er
 }
 
 // nextDelay возвращает увеличенный интервал с crypto-jitter.
-func nextDelay(prev time.Duration, cfg *config.Config) time.Duration {
-	backoff := float64(prev) * cfg.Factor
-	if backoff > float64(cfg.Max) {
-		backoff = float64(cfg.Max)
+func nextDelay(prev time.Duration, p Policy) time.Duration {
+	backoff := float64(prev) * p.Factor
+	if backoff > float64(p.Max) {
+		backoff = float64(p.Max)
 	}
 
-	if cfg.Jitter {
+	if p.Jitter {
 		jitterMax := int64(backoff / 2)
 		if jitterMax > 0 {
 			if n, err := rand.Int(rand.Reader, big.NewInt(jitterMax)); err == nil {
@@ -72,18 +130,3 @@ func nextDelay(prev time.Duration, cfg *config.Config) time.Duration {
 
 	return time.Duration(backoff)
 }
-
-func sanitize(cfg *config.Config) {
-	if cfg.Attempts <= 0 {
-		cfg.Attempts = 3
-	}
-	if cfg.Initial <= 0 {
-		cfg.Initial = time.Second
-	}
-	if cfg.Max <= 0 {
-		cfg.Max = 30 * time.Second
-	}
-	if cfg.Factor < 1 {
-		cfg.Factor = 2.0
-	}
-}